@@ -1,28 +1,239 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/caarlos0/env/v9"
+	"github.com/gin-gonic/gin"
 	"github.com/schubergphilis/mcvs-integrationtest-services/internal/app/stubserver"
 	"github.com/schubergphilis/mcvs-integrationtest-services/internal/pkg/constants"
 	log "github.com/sirupsen/logrus"
 )
 
+// Config represents the configuration.
+type Config struct {
+	ServerConfig ServerConfig
+	LogConfig    LogConfig
+	TLSConfig    TLSConfig
+
+	// StubDefinitionsDir is an optional directory with stub definition files to load at startup.
+	StubDefinitionsDir string `env:"STUB_DEFINITIONS_DIR"`
+	// ResponseFilesDir is an optional directory with files that stubs can serve as response body.
+	ResponseFilesDir string `env:"RESPONSE_FILES_DIR"`
+	// OpenAPISpecFiles are optional OpenAPI 3 documents to generate responses from at startup.
+	OpenAPISpecFiles []string `env:"OPENAPI_SPEC_FILES"`
+	// OpenAPIContractFile is an optional OpenAPI 3 document to validate requests against.
+	OpenAPIContractFile string `env:"OPENAPI_CONTRACT_FILE"`
+	// OpenAPIContractReject answers requests that violate the contract with 400.
+	OpenAPIContractReject bool `env:"OPENAPI_CONTRACT_REJECT"`
+	// GRPCDescriptorSetFiles are optional binary FileDescriptorSet files with the gRPC services to stub.
+	GRPCDescriptorSetFiles []string `env:"GRPC_DESCRIPTOR_SET_FILES"`
+}
+
+// ServerConfig represents the server configuration.
+type ServerConfig struct {
+	Address            string `env:"ADDRESS"               envDefault:":8080"`
+	AdminBasePath      string `env:"ADMIN_BASE_PATH"       envDefault:"/stubserver"`
+	JournalSize        int    `env:"JOURNAL_SIZE"          envDefault:"1000"`
+	MaxLoggedBodyBytes int    `env:"MAX_LOGGED_BODY_BYTES" envDefault:"10240"`
+	// GRPCAddress is the address of the gRPC server, which is only started when it is set.
+	GRPCAddress string `env:"GRPC_ADDRESS"`
+}
+
+// LogConfig represents the logging configuration.
+type LogConfig struct {
+	Level   string `env:"LOG_LEVEL"  envDefault:"info"`
+	Format  string `env:"LOG_FORMAT" envDefault:"text"`
+	GinMode string `env:"GIN_MODE"   envDefault:"debug"`
+}
+
+// TLSConfig represents the TLS configuration. The server serves HTTPS when a certificate is set or
+// TLS is enabled, in which case a CA and a certificate for the SANs are generated.
+type TLSConfig struct {
+	Enabled      bool     `env:"TLS_ENABLED"`
+	CertFile     string   `env:"TLS_CERT_FILE"`
+	KeyFile      string   `env:"TLS_KEY_FILE"`
+	SANs         []string `env:"TLS_SANS"           envDefault:"localhost,127.0.0.1"`
+	ClientAuth   string   `env:"TLS_CLIENT_AUTH"    envDefault:"none"`
+	ClientCAFile string   `env:"TLS_CLIENT_CA_FILE"`
+}
+
+// NewConfig returns the config.
+func NewConfig() (*Config, error) {
+	cfg := Config{}
+
+	err := env.Parse(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func configureLogging(cfg *LogConfig) error {
+	level, err := log.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	log.SetLevel(level)
+
+	switch cfg.Format {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return fmt.Errorf("invalid log format: %s", cfg.Format)
+	}
+
+	switch cfg.GinMode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+		gin.SetMode(cfg.GinMode)
+	default:
+		return fmt.Errorf("invalid gin mode: %s", cfg.GinMode)
+	}
+
+	return nil
+}
+
 func main() {
-	server := stubserver.NewServer()
+	cfg, err := NewConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = configureLogging(&cfg.LogConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := stubserver.NewServerWithConfig(stubserver.Config{
+		AdminBasePath:      cfg.ServerConfig.AdminBasePath,
+		JournalSize:        cfg.ServerConfig.JournalSize,
+		MaxLoggedBodyBytes: cfg.ServerConfig.MaxLoggedBodyBytes,
+	})
+
+	if cfg.StubDefinitionsDir != "" {
+		err = server.LoadStubDefinitions(cfg.StubDefinitionsDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, specFile := range cfg.OpenAPISpecFiles {
+		err = server.LoadOpenAPISpec(specFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.OpenAPIContractFile != "" {
+		err = server.LoadOpenAPIContract(cfg.OpenAPIContractFile, cfg.OpenAPIContractReject)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, descriptorSetFile := range cfg.GRPCDescriptorSetFiles {
+		err = server.LoadDescriptorSet(descriptorSetFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.ResponseFilesDir != "" {
+		err = server.SetResponseFilesDir(cfg.ResponseFilesDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if cfg.ServerConfig.GRPCAddress != "" {
+		go serveGRPC(server, cfg.ServerConfig.GRPCAddress)
+	}
 
 	httpServer := &http.Server{
-		Addr:              ":8080",
+		Addr:              cfg.ServerConfig.Address,
 		Handler:           server.Router,
 		ReadHeaderTimeout: constants.DefaultHTTPTimeout,
 	}
 
-	log.Info("Starting server on :8080")
+	shutdown := make(chan struct{})
 
-	err := httpServer.ListenAndServe()
-	if err != nil {
+	go func() {
+		shutdownOnSignal(httpServer)
+		close(shutdown)
+	}()
+
+	if cfg.TLSConfig.Enabled || cfg.TLSConfig.CertFile != "" {
+		httpServer.TLSConfig, err = server.ConfigureTLS(stubserver.TLSConfig{
+			CertFile:     cfg.TLSConfig.CertFile,
+			KeyFile:      cfg.TLSConfig.KeyFile,
+			SANs:         cfg.TLSConfig.SANs,
+			ClientAuth:   cfg.TLSConfig.ClientAuth,
+			ClientCAFile: cfg.TLSConfig.ClientCAFile,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// HTTP/2 connections cannot be hijacked, which the connection faults of stubs rely on.
+		httpServer.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+
+		log.Infof("Starting server on %s with TLS", cfg.ServerConfig.Address)
+
+		err = httpServer.ListenAndServeTLS("", "")
+	} else {
+		log.Infof("Starting server on %s", cfg.ServerConfig.Address)
+
+		err = httpServer.ListenAndServe()
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
 
+	<-shutdown
+	server.Close()
+
 	log.Println("server closed")
 }
+
+// shutdownOnSignal gracefully shuts down the HTTP server on SIGINT or SIGTERM.
+func shutdownOnSignal(httpServer *http.Server) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), constants.DefaultHTTPTimeout)
+	defer cancel()
+
+	err := httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		log.WithError(err).Error("unable to shut down server")
+	}
+}
+
+func serveGRPC(server *stubserver.Server, address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Infof("Starting gRPC server on %s", address)
+
+	err = server.GRPCServer().Serve(listener)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,125 @@
+package stubserver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const contentTypeHeader = "Content-Type"
+
+// SetResponseFilesDir sets the directory from which response bodies that reference a file are
+// read. Files outside the directory cannot be referenced.
+func (s *Server) SetResponseFilesDir(dir string) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return fmt.Errorf("unable to open response files directory: %w", err)
+	}
+
+	s.responseFiles = root
+
+	return nil
+}
+
+func validateResponseBodies(ep *EndpointConfiguration) error {
+	response := defaultResponse(ep)
+
+	err := validateResponseBody(&response)
+	if err != nil {
+		return err
+	}
+
+	for i, response := range ep.Responses {
+		err = validateResponseBody(&response)
+		if err != nil {
+			return fmt.Errorf("response %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func validateResponseBody(response *Response) error {
+	bodies := 0
+
+	for _, body := range []string{response.Body, response.BodyBase64, response.BodyFile} {
+		if body != "" {
+			bodies++
+		}
+	}
+
+	if bodies > 1 {
+		return fmt.Errorf("only one of response body, base64 response body and response body file can be set")
+	}
+
+	if response.BodyBase64 != "" {
+		_, err := base64.StdEncoding.DecodeString(response.BodyBase64)
+		if err != nil {
+			return fmt.Errorf("invalid base64 response body: %w", err)
+		}
+	}
+
+	if response.BodyFile != "" && !filepath.IsLocal(response.BodyFile) {
+		return fmt.Errorf("response body file must be a relative path within the response files directory: %s", response.BodyFile)
+	}
+
+	return nil
+}
+
+// resolveResponseBody replaces the body of a response with a base64 body or body file by its
+// content. The content type is inferred when the response does not define one.
+func (s *Server) resolveResponseBody(response *Response) error {
+	var (
+		content []byte
+		err     error
+	)
+
+	switch {
+	case response.BodyBase64 != "":
+		content, err = base64.StdEncoding.DecodeString(response.BodyBase64)
+		if err != nil {
+			return fmt.Errorf("invalid base64 response body: %w", err)
+		}
+	case response.BodyFile != "":
+		if s.responseFiles == nil {
+			return fmt.Errorf("response files directory is not configured")
+		}
+
+		content, err = s.responseFiles.ReadFile(response.BodyFile)
+		if err != nil {
+			return fmt.Errorf("unable to read response body file: %w", err)
+		}
+	default:
+		return nil
+	}
+
+	response.Body = string(content)
+
+	if _, present := lookupHeader(response.Headers, contentTypeHeader); !present {
+		headers := make(map[string]string, len(response.Headers)+1)
+		maps.Copy(headers, response.Headers)
+		headers[contentTypeHeader] = detectContentType(response.BodyFile, content)
+		response.Headers = headers
+	}
+
+	return nil
+}
+
+// isBinary reports whether the body of the response was resolved from a base64 body or body file.
+func (r *Response) isBinary() bool {
+	return r.BodyBase64 != "" || r.BodyFile != ""
+}
+
+// detectContentType infers the content type from the file extension, falling back to sniffing
+// the content.
+func detectContentType(name string, content []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+
+	return http.DetectContentType(content)
+}
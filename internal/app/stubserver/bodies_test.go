@@ -0,0 +1,66 @@
+package stubserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResponseBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      Response
+		expectedError string
+	}{
+		{name: "empty body", response: Response{}},
+		{name: "base64 body", response: Response{BodyBase64: "JVBERi0xLjQ="}},
+		{name: "body file", response: Response{BodyFile: "documents/invoice.pdf"}},
+		{name: "invalid base64 body", response: Response{BodyBase64: "%%%"}, expectedError: "invalid base64 response body"},
+		{name: "body file outside directory", response: Response{BodyFile: "../secrets.txt"}, expectedError: "relative path"},
+		{name: "absolute body file", response: Response{BodyFile: "/etc/passwd"}, expectedError: "relative path"},
+		{name: "several bodies", response: Response{Body: "a", BodyFile: "a.txt"}, expectedError: "only one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResponseBody(&tt.response)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestResolveResponseBody(t *testing.T) {
+	server := NewServer()
+
+	response := Response{BodyFile: "invoice.pdf"}
+	err := server.resolveResponseBody(&response)
+	assert.ErrorContains(t, err, "response files directory is not configured")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invoice.pdf"), []byte("%PDF-1.4"), 0o600))
+	require.NoError(t, server.SetResponseFilesDir(dir))
+
+	response = Response{BodyFile: "invoice.pdf"}
+	require.NoError(t, server.resolveResponseBody(&response))
+	assert.Equal(t, "%PDF-1.4", response.Body)
+	assert.Equal(t, "application/pdf", response.Headers[contentTypeHeader])
+
+	response = Response{BodyBase64: "iVBORw0KGgo=", Headers: map[string]string{"content-type": "image/x-custom"}}
+	require.NoError(t, server.resolveResponseBody(&response))
+	assert.Equal(t, "\x89PNG\r\n\x1a\n", response.Body)
+	assert.Equal(t, map[string]string{"content-type": "image/x-custom"}, response.Headers)
+
+	response = Response{BodyBase64: "iVBORw0KGgo="}
+	require.NoError(t, server.resolveResponseBody(&response))
+	assert.Equal(t, "image/png", response.Headers[contentTypeHeader])
+
+	response = Response{BodyFile: "missing.pdf"}
+	assert.ErrorContains(t, server.resolveResponseBody(&response), "unable to read response body file")
+}
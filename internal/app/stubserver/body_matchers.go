@@ -0,0 +1,330 @@
+package stubserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// BodyMatchType represents the way the body of a request is matched.
+type BodyMatchType string
+
+const (
+	// BodyMatchTypeExact matches when the body is equal to the expected value.
+	BodyMatchTypeExact BodyMatchType = "exact"
+	// BodyMatchTypeJSON matches when the body is JSON that is equal to the expected value, ignoring key order and whitespace.
+	BodyMatchTypeJSON BodyMatchType = "json"
+	// BodyMatchTypeJSONSubset matches when the body is JSON that contains at least the expected value.
+	BodyMatchTypeJSONSubset BodyMatchType = "jsonSubset"
+	// BodyMatchTypeJSONPath matches when the JSONPath expression selects a value, optionally satisfying a matcher.
+	BodyMatchTypeJSONPath BodyMatchType = "jsonPath"
+	// BodyMatchTypeRegex matches when the body matches the expected regular expression.
+	BodyMatchTypeRegex BodyMatchType = "regex"
+	// BodyMatchTypeForm matches when the body is URL encoded form data whose fields satisfy the field matchers.
+	BodyMatchTypeForm BodyMatchType = "form"
+)
+
+const (
+	exactBodyMatchWeight   = 3
+	partialBodyMatchWeight = 2
+)
+
+// BodyMatcher describes how the body of a request should be matched.
+type BodyMatcher struct {
+	MatchType BodyMatchType
+	// Value is the expected body for the exact, json, jsonSubset and regex match types.
+	Value string
+	// Expression is the JSONPath expression for the jsonPath match type.
+	Expression string
+	// Matcher optionally constrains the values selected by Expression.
+	Matcher *ValueMatcher
+	// FormFields contains the field matchers for the form match type.
+	FormFields map[string]ValueMatcher
+
+	// regex is the compiled Value of the regex match type once it is compiled.
+	regex *regexp.Regexp
+}
+
+// String returns a textual representation of the matcher, which is used to build endpoint IDs.
+func (bm BodyMatcher) String() string {
+	switch bm.MatchType {
+	case BodyMatchTypeJSONPath:
+		if bm.Matcher != nil {
+			return fmt.Sprintf("%s(%s=%s)", bm.MatchType, bm.Expression, bm.Matcher)
+		}
+
+		return fmt.Sprintf("%s(%s)", bm.MatchType, bm.Expression)
+	case BodyMatchTypeForm:
+		builder := strings.Builder{}
+		builder.WriteString(fmt.Sprintf("%s(", bm.MatchType))
+
+		for _, key := range sortedKeys(bm.FormFields) {
+			builder.WriteString(fmt.Sprintf("%s~%s,", key, bm.FormFields[key]))
+		}
+
+		builder.WriteString(")")
+
+		return builder.String()
+	}
+
+	return fmt.Sprintf("%s(%s)", bm.MatchType, bm.Value)
+}
+
+// Validate checks whether the body matcher is complete and its expressions are valid.
+//
+//nolint:cyclop
+func (bm BodyMatcher) Validate() error {
+	switch bm.MatchType {
+	case BodyMatchTypeExact:
+		return nil
+	case BodyMatchTypeJSON, BodyMatchTypeJSONSubset:
+		if !json.Valid([]byte(bm.Value)) {
+			return fmt.Errorf("invalid JSON value for %s body matcher", bm.MatchType)
+		}
+
+		return nil
+	case BodyMatchTypeRegex:
+		_, err := regexp.Compile(bm.Value)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", bm.Value, err)
+		}
+
+		return nil
+	case BodyMatchTypeJSONPath:
+		_, err := compileJSONPath(bm.Expression)
+		if err != nil {
+			return err
+		}
+
+		if bm.Matcher != nil {
+			return bm.Matcher.Validate()
+		}
+
+		return nil
+	case BodyMatchTypeForm:
+		if len(bm.FormFields) == 0 {
+			return fmt.Errorf("form body matcher requires at least one form field")
+		}
+
+		for name, matcher := range bm.FormFields {
+			err := matcher.Validate()
+			if err != nil {
+				return fmt.Errorf("form field %s: %w", name, err)
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("invalid body match type: %s", bm.MatchType)
+}
+
+// compileBodyMatchers returns the body matchers with their regular expressions compiled, so that
+// matching bodies does not compile them again.
+func compileBodyMatchers(matchers []BodyMatcher) []BodyMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	compiled := slices.Clone(matchers)
+
+	for i := range compiled {
+		matcher := &compiled[i]
+
+		if matcher.MatchType == BodyMatchTypeRegex {
+			matcher.regex, _ = regexp.Compile(matcher.Value)
+		}
+
+		if matcher.Matcher != nil {
+			valueMatcher := matcher.Matcher.compile()
+			matcher.Matcher = &valueMatcher
+		}
+
+		matcher.FormFields = compileValueMatchers(matcher.FormFields)
+	}
+
+	return compiled
+}
+
+// Matches reports whether the given request body satisfies the matcher.
+func (bm BodyMatcher) Matches(body []byte) bool {
+	switch bm.MatchType {
+	case BodyMatchTypeExact:
+		return string(body) == bm.Value
+	case BodyMatchTypeRegex:
+		if bm.regex != nil {
+			return bm.regex.Match(body)
+		}
+
+		matched, err := regexp.Match(bm.Value, body)
+
+		return err == nil && matched
+	case BodyMatchTypeJSON:
+		return matchJSON(body, bm.Value, reflect.DeepEqual)
+	case BodyMatchTypeJSONSubset:
+		return matchJSON(body, bm.Value, func(actual, expected any) bool {
+			return isJSONSubset(expected, actual)
+		})
+	case BodyMatchTypeJSONPath:
+		return bm.matchesJSONPath(body)
+	case BodyMatchTypeForm:
+		return bm.matchesForm(body)
+	}
+
+	return false
+}
+
+func (bm BodyMatcher) matchesJSONPath(body []byte) bool {
+	var document any
+
+	err := json.Unmarshal(body, &document)
+	if err != nil {
+		return false
+	}
+
+	results, err := evaluateJSONPath(bm.Expression, document)
+	if err != nil || len(results) == 0 {
+		return false
+	}
+
+	if bm.Matcher == nil {
+		return true
+	}
+
+	for _, result := range results {
+		if bm.Matcher.Matches(jsonValueToString(result), true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (bm BodyMatcher) matchesForm(body []byte) bool {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return false
+	}
+
+	for name, matcher := range bm.FormFields {
+		if !matcher.Matches(values.Get(name), values.Has(name)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchJSON(body []byte, expected string, compare func(actual, expected any) bool) bool {
+	var actualValue, expectedValue any
+
+	err := json.Unmarshal(body, &actualValue)
+	if err != nil {
+		return false
+	}
+
+	err = json.Unmarshal([]byte(expected), &expectedValue)
+	if err != nil {
+		return false
+	}
+
+	return compare(actualValue, expectedValue)
+}
+
+// isJSONSubset reports whether expected is contained in actual. Objects may have additional keys
+// and every element of an expected array must match at least one element of the actual array.
+func isJSONSubset(expected, actual any) bool {
+	switch expectedTyped := expected.(type) {
+	case map[string]any:
+		actualObject, ok := actual.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for key, expectedValue := range expectedTyped {
+			actualValue, exists := actualObject[key]
+			if !exists || !isJSONSubset(expectedValue, actualValue) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		actualArray, ok := actual.([]any)
+		if !ok {
+			return false
+		}
+
+		for _, expectedElement := range expectedTyped {
+			if !containsJSONSubset(actualArray, expectedElement) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(expected, actual)
+}
+
+func containsJSONSubset(actual []any, expected any) bool {
+	for _, actualElement := range actual {
+		if isJSONSubset(expected, actualElement) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateBodyMatchers(matchers []BodyMatcher) error {
+	for _, matcher := range matchers {
+		err := matcher.Validate()
+		if err != nil {
+			return fmt.Errorf("body matcher: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// bodyMatchWeight returns the score a satisfied body matcher adds, so that stubs expecting the
+// complete body win over stubs that only look at a part of it.
+func bodyMatchWeight(matchType BodyMatchType) int {
+	switch matchType {
+	case BodyMatchTypeExact, BodyMatchTypeJSON:
+		return exactBodyMatchWeight
+	case BodyMatchTypeJSONSubset, BodyMatchTypeJSONPath, BodyMatchTypeForm:
+		return partialBodyMatchWeight
+	}
+
+	return 1
+}
+
+// matchesAllBodyMatchers reports whether the body satisfies every matcher.
+func matchesAllBodyMatchers(matchers []BodyMatcher, body []byte) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(body) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// scoreBodyMatchers returns the score of the body matchers that the body satisfies.
+func scoreBodyMatchers(matchers []BodyMatcher, body []byte) int {
+	score := 0
+
+	for _, matcher := range matchers {
+		if matcher.Matches(body) {
+			score += bodyMatchWeight(matcher.MatchType)
+		}
+	}
+
+	return score
+}
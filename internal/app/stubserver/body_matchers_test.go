@@ -0,0 +1,233 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestBodyMatcherMatches(t *testing.T) {
+	body := `{"orderId":"123","customer":{"name":"John","tier":"gold"},"items":[{"sku":"a"},{"sku":"b"}]}`
+
+	tests := []struct {
+		name     string
+		matcher  BodyMatcher
+		body     string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeExact, Value: "hello"},
+			body:     "hello",
+			expected: true,
+		},
+		{
+			name:     "exact mismatch",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeExact, Value: "hello"},
+			body:     "hello world",
+			expected: false,
+		},
+		{
+			name: "json equality ignores key order and whitespace",
+			matcher: BodyMatcher{
+				MatchType: BodyMatchTypeJSON,
+				Value:     `{"items":[{"sku":"a"},{"sku":"b"}], "customer":{"tier":"gold","name":"John"}, "orderId":"123"}`,
+			},
+			body:     body,
+			expected: true,
+		},
+		{
+			name:     "json equality fails on additional keys",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeJSON, Value: `{"orderId":"123"}`},
+			body:     body,
+			expected: false,
+		},
+		{
+			name:     "json subset",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeJSONSubset, Value: `{"customer":{"tier":"gold"},"items":[{"sku":"b"}]}`},
+			body:     body,
+			expected: true,
+		},
+		{
+			name:     "json subset mismatch",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeJSONSubset, Value: `{"customer":{"tier":"silver"}}`},
+			body:     body,
+			expected: false,
+		},
+		{
+			name:     "json subset on invalid json body",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeJSONSubset, Value: `{}`},
+			body:     "not json",
+			expected: false,
+		},
+		{
+			name:     "json path presence",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeJSONPath, Expression: "$.customer.name"},
+			body:     body,
+			expected: true,
+		},
+		{
+			name: "json path with matcher",
+			matcher: BodyMatcher{
+				MatchType:  BodyMatchTypeJSONPath,
+				Expression: "$.items[*].sku",
+				Matcher:    &ValueMatcher{MatchType: MatchTypeExact, Value: "b"},
+			},
+			body:     body,
+			expected: true,
+		},
+		{
+			name:     "json path absent",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeJSONPath, Expression: "$.customer.email"},
+			body:     body,
+			expected: false,
+		},
+		{
+			name:     "regex",
+			matcher:  BodyMatcher{MatchType: BodyMatchTypeRegex, Value: `"orderId":"\d+"`},
+			body:     body,
+			expected: true,
+		},
+		{
+			name: "form fields",
+			matcher: BodyMatcher{
+				MatchType: BodyMatchTypeForm,
+				FormFields: map[string]ValueMatcher{
+					"grant_type": {MatchType: MatchTypeExact, Value: "client_credentials"},
+					"scope":      {MatchType: MatchTypeContains, Value: "read"},
+				},
+			},
+			body:     "grant_type=client_credentials&scope=read+write",
+			expected: true,
+		},
+		{
+			name: "form field missing",
+			matcher: BodyMatcher{
+				MatchType:  BodyMatchTypeForm,
+				FormFields: map[string]ValueMatcher{"client_id": {MatchType: MatchTypePresent}},
+			},
+			body:     "grant_type=client_credentials",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.matcher.Matches([]byte(tt.body)))
+		})
+	}
+}
+
+func TestBodyMatcherValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		matcher      BodyMatcher
+		errorMessage string
+	}{
+		{
+			name:         "invalid json",
+			matcher:      BodyMatcher{MatchType: BodyMatchTypeJSON, Value: "{"},
+			errorMessage: "invalid JSON value for json body matcher",
+		},
+		{
+			name:         "invalid regex",
+			matcher:      BodyMatcher{MatchType: BodyMatchTypeRegex, Value: "("},
+			errorMessage: "invalid regular expression",
+		},
+		{
+			name:         "invalid json path",
+			matcher:      BodyMatcher{MatchType: BodyMatchTypeJSONPath, Expression: "order.id"},
+			errorMessage: "invalid JSONPath expression",
+		},
+		{
+			name:         "form without fields",
+			matcher:      BodyMatcher{MatchType: BodyMatchTypeForm},
+			errorMessage: "form body matcher requires at least one form field",
+		},
+		{
+			name:         "unknown type",
+			matcher:      BodyMatcher{MatchType: "xml"},
+			errorMessage: "invalid body match type: xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.matcher.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMessage)
+		})
+	}
+}
+
+func TestMatchEndpointWithBodyMatchers(t *testing.T) {
+	rm := NewResponseManager()
+
+	endpoints := []EndpointConfiguration{
+		{
+			EndpointID: EndpointID{
+				Path:         "/api/v1/orders",
+				HTTPMethod:   "POST",
+				BodyMatchers: []BodyMatcher{{MatchType: BodyMatchTypeJSONSubset, Value: `{"type":"express"}`}},
+			},
+			ResponseBody: "express",
+		},
+		{
+			EndpointID: EndpointID{
+				Path:         "/api/v1/orders",
+				HTTPMethod:   "POST",
+				BodyMatchers: []BodyMatcher{{MatchType: BodyMatchTypeJSON, Value: `{"type":"express","id":"vip"}`}},
+			},
+			ResponseBody: "vip",
+		},
+	}
+
+	for _, endpoint := range endpoints {
+		require.NoError(t, rm.AddEndpoint(endpoint))
+	}
+
+	result, err := rm.MatchEndpoint(&EndpointID{
+		Path:       "/api/v1/orders",
+		HTTPMethod: "POST",
+		Body:       []byte(`{"id":"1","type":"express"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "express", result.ResponseBody)
+
+	result, err = rm.MatchEndpoint(&EndpointID{
+		Path:       "/api/v1/orders",
+		HTTPMethod: "POST",
+		Body:       []byte(`{"id":"vip","type":"express"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "vip", result.ResponseBody, "the exact body match is more specific than the subset")
+}
+
+func TestMatchEndpointRejectsFailingBodyMatchers(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{
+			Path:         "/api/v1/orders",
+			HTTPMethod:   "POST",
+			BodyMatchers: []BodyMatcher{{MatchType: BodyMatchTypeJSONSubset, Value: `{"type":"express"}`}},
+		},
+		ResponseBody: "express",
+	}))
+
+	request := EndpointID{Path: "/api/v1/orders", HTTPMethod: "POST", Body: []byte(`{"type":"standard"}`)}
+
+	_, err := rm.MatchEndpoint(&request)
+	require.Error(t, err, "a body that matches no body matcher is not served")
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		EndpointID:   EndpointID{Path: "/api/v1/orders", HTTPMethod: "POST"},
+		ResponseBody: "fallback",
+	}))
+
+	result, err := rm.MatchEndpoint(&request)
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", result.ResponseBody)
+}
@@ -0,0 +1,329 @@
+package stubserver
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/schubergphilis/mcvs-integrationtest-services/internal/pkg/constants"
+	log "github.com/sirupsen/logrus"
+)
+
+// callbackClient sends the callbacks of every namespace.
+var callbackClient = &http.Client{Timeout: constants.DefaultHTTPTimeout}
+
+// Callback describes a request that is sent after an endpoint was served, like the webhook call of
+// an asynchronous upstream. The URL, headers and body are templates with the triggering request as
+// data, see TemplateData.
+type Callback struct {
+	URL string
+	// Method defaults to POST.
+	Method  string
+	Headers map[string]string
+	Body    string
+	// Delay is the time to wait after the response before the callback is sent.
+	Delay time.Duration
+	// Retries is the number of extra attempts when an attempt fails or is answered with a status
+	// code other than 2xx, with RetryDelay between the attempts.
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// CallbackAttempt represents an attempt to send a callback and its result.
+type CallbackAttempt struct {
+	// ID is a sequence number that reflects the order in which attempts finished.
+	ID        int64
+	Timestamp time.Time
+	StubID    string
+	// Callback is the index of the callback in the callbacks of the stub, Attempt counts the
+	// attempts of the callback from 1.
+	Callback int
+	Attempt  int
+	Method   string
+	URL      string
+	Body     string
+	// StatusCode is the status code of the response, 0 when the attempt failed with Error.
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
+
+// succeeded reports whether the callback was received with a 2xx status code.
+func (a *CallbackAttempt) succeeded() bool {
+	return a.Error == "" && a.StatusCode >= http.StatusOK && a.StatusCode < http.StatusMultipleChoices
+}
+
+// CallbackJournal keeps the most recent callback attempts in memory.
+type CallbackJournal struct {
+	mu       sync.RWMutex
+	attempts []CallbackAttempt
+	maxSize  int
+	lastID   int64
+}
+
+// NewCallbackJournal creates a new instance of CallbackJournal that keeps at most maxSize attempts.
+func NewCallbackJournal(maxSize int) *CallbackJournal {
+	if maxSize <= 0 {
+		maxSize = defaultJournalSize
+	}
+
+	return &CallbackJournal{attempts: make([]CallbackAttempt, 0), maxSize: maxSize}
+}
+
+// Record adds an attempt to the journal, dropping the oldest attempt when the journal is full.
+func (j *CallbackJournal) Record(attempt CallbackAttempt) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.attempts) >= j.maxSize {
+		j.attempts = j.attempts[len(j.attempts)-j.maxSize+1:]
+	}
+
+	j.lastID++
+	attempt.ID = j.lastID
+
+	j.attempts = append(j.attempts, attempt)
+}
+
+// Find returns the attempts of the callbacks of the stub, or of every stub when stubID is empty,
+// oldest first.
+func (j *CallbackJournal) Find(stubID string) []CallbackAttempt {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	attempts := make([]CallbackAttempt, 0)
+
+	for _, attempt := range j.attempts {
+		if stubID == "" || attempt.StubID == stubID {
+			attempts = append(attempts, attempt)
+		}
+	}
+
+	return attempts
+}
+
+// Clear removes all attempts from the journal.
+func (j *CallbackJournal) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.attempts = make([]CallbackAttempt, 0)
+}
+
+func validateCallbacks(callbacks []Callback) error {
+	for i, callback := range callbacks {
+		err := validateCallback(&callback)
+		if err != nil {
+			return fmt.Errorf("callback %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func validateCallback(callback *Callback) error {
+	if callback.URL == "" {
+		return fmt.Errorf("URL is required")
+	}
+
+	if !strings.Contains(callback.URL, "{{") {
+		target, err := url.Parse(callback.URL)
+		if err != nil || target.Scheme != "http" && target.Scheme != "https" || target.Host == "" {
+			return fmt.Errorf("invalid URL: %s", callback.URL)
+		}
+	}
+
+	validMethods := []string{"", "GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"}
+	if !slices.Contains(validMethods, callback.Method) {
+		return fmt.Errorf("invalid HTTP method: %s", callback.Method)
+	}
+
+	if callback.Delay < 0 || callback.RetryDelay < 0 {
+		return fmt.Errorf("delays must not be negative")
+	}
+
+	if callback.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+
+	texts := []string{callback.URL, callback.Body}
+	for _, value := range callback.Headers {
+		texts = append(texts, value)
+	}
+
+	for _, text := range texts {
+		_, err := template.New("callback").Funcs(templateFuncs(nil)).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// callbackSender sends the callbacks of a namespace in the background. Callbacks that are still
+// pending can be cancelled, for example when the stubs of the namespace are reset.
+type callbackSender struct {
+	mu sync.Mutex
+	//nolint:containedctx // the context bounds the lifetime of the pending callbacks.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  bool
+	journal *CallbackJournal
+}
+
+func newCallbackSender(journal *CallbackJournal) *callbackSender {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &callbackSender{ctx: ctx, cancel: cancel, journal: journal}
+}
+
+// Fire sends the callbacks of a served stub in the background and records every attempt.
+func (s *callbackSender) Fire(stubID string, callbacks []Callback, data *TemplateData) {
+	s.mu.Lock()
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	for i, callback := range callbacks {
+		go sendCallback(ctx, s.journal, stubID, i, &callback, data)
+	}
+}
+
+// CancelPending stops the callbacks that are waiting for their delay or are being sent. Callbacks
+// of stubs served afterwards are sent as usual.
+func (s *callbackSender) CancelPending() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancel()
+
+	if !s.closed {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+}
+
+// Close stops the pending callbacks and every callback fired afterwards.
+func (s *callbackSender) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.cancel()
+}
+
+// sendCallback renders the callback and sends it after its delay, retrying failed attempts, until
+// the context is cancelled.
+func sendCallback(ctx context.Context, journal *CallbackJournal, stubID string, index int, callback *Callback, data *TemplateData) {
+	attempt := CallbackAttempt{StubID: stubID, Callback: index, Method: cmp.Or(callback.Method, http.MethodPost)}
+
+	rendered, err := renderCallback(callback, data)
+	if err != nil {
+		attempt.Timestamp = time.Now()
+		attempt.Attempt = 1
+		attempt.Error = fmt.Sprintf("unable to render callback: %v", err)
+		journal.Record(attempt)
+
+		log.WithError(err).WithFields(log.Fields{"stubID": stubID}).Error("unable to render callback")
+
+		return
+	}
+
+	attempt.URL = rendered.URL
+	attempt.Body = rendered.Body
+
+	sleep(ctx, callback.Delay)
+
+	for number := 1; number <= callback.Retries+1; number++ {
+		if number > 1 {
+			sleep(ctx, callback.RetryDelay)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		attempt.Attempt = number
+		attempt.Timestamp = time.Now()
+		attempt.StatusCode, attempt.Error = sendCallbackAttempt(ctx, &attempt, rendered.Headers)
+		attempt.Duration = time.Since(attempt.Timestamp)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		journal.Record(attempt)
+
+		if attempt.succeeded() {
+			return
+		}
+
+		log.WithFields(log.Fields{"stubID": stubID, "url": attempt.URL, "attempt": number, "statusCode": attempt.StatusCode, "error": attempt.Error}).
+			Warn("callback attempt failed")
+	}
+}
+
+// sendCallbackAttempt sends the request of a callback attempt. It returns the status code of the
+// response or the error that made the attempt fail.
+func sendCallbackAttempt(ctx context.Context, attempt *CallbackAttempt, headers map[string]string) (int, string) {
+	ctx, cancel := context.WithTimeout(ctx, constants.DefaultHTTPTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, attempt.Method, attempt.URL, strings.NewReader(attempt.Body))
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := callbackClient.Do(request)
+	if err != nil {
+		return 0, err.Error()
+	}
+
+	defer response.Body.Close()
+
+	_, err = io.Copy(io.Discard, response.Body)
+	if err != nil {
+		log.WithError(err).Debug("unable to read callback response")
+	}
+
+	return response.StatusCode, ""
+}
+
+// renderCallback executes the templates in the URL, headers and body of the callback.
+func renderCallback(callback *Callback, data *TemplateData) (Callback, error) {
+	rendered := *callback
+
+	var err error
+
+	rendered.URL, err = renderTemplate(callback.URL, data)
+	if err != nil {
+		return Callback{}, err
+	}
+
+	rendered.Body, err = renderTemplate(callback.Body, data)
+	if err != nil {
+		return Callback{}, err
+	}
+
+	rendered.Headers = make(map[string]string, len(callback.Headers))
+
+	for key, value := range callback.Headers {
+		rendered.Headers[key], err = renderTemplate(value, data)
+		if err != nil {
+			return Callback{}, err
+		}
+	}
+
+	return rendered, nil
+}
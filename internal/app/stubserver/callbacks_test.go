@@ -0,0 +1,382 @@
+package stubserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestValidateCallback(t *testing.T) {
+	tests := []struct {
+		name          string
+		callback      Callback
+		errorContains string
+	}{
+		{
+			name:     "valid callback",
+			callback: Callback{URL: "http://localhost/hooks", Method: http.MethodPut, Body: `{"id":"{{.PathParams.id}}"}`, Retries: 2},
+		},
+		{
+			name:     "templated URL",
+			callback: Callback{URL: `{{index .Headers "Callback-Url"}}`},
+		},
+		{
+			name:          "missing URL",
+			callback:      Callback{},
+			errorContains: "URL is required",
+		},
+		{
+			name:          "relative URL",
+			callback:      Callback{URL: "/hooks"},
+			errorContains: "invalid URL: /hooks",
+		},
+		{
+			name:          "invalid method",
+			callback:      Callback{URL: "http://localhost/hooks", Method: "SEND"},
+			errorContains: "invalid HTTP method: SEND",
+		},
+		{
+			name:          "negative delay",
+			callback:      Callback{URL: "http://localhost/hooks", RetryDelay: -time.Second},
+			errorContains: "delays must not be negative",
+		},
+		{
+			name:          "negative retries",
+			callback:      Callback{URL: "http://localhost/hooks", Retries: -1},
+			errorContains: "retries must not be negative",
+		},
+		{
+			name:          "invalid header template",
+			callback:      Callback{URL: "http://localhost/hooks", Headers: map[string]string{"X-Id": "{{.Body"}},
+			errorContains: "invalid template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCallback(&tt.callback)
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestRenderCallback(t *testing.T) {
+	callback := Callback{
+		URL:     "http://localhost/orders/{{.PathParams.id}}/events",
+		Headers: map[string]string{"X-Request-Id": `{{index .Headers "X-Request-Id"}}`},
+		Body:    `{"status":"{{.JSON.status}}"}`,
+	}
+	ei := EndpointID{
+		Path:           "/orders/42",
+		HTTPMethod:     http.MethodPost,
+		HeadersToMatch: map[string]string{"X-Request-Id": "abc"},
+		Body:           []byte(`{"status":"paid"}`),
+	}
+
+	rendered, err := renderCallback(&callback, NewTemplateData(&ei, map[string]string{"id": "42"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://localhost/orders/42/events", rendered.URL)
+	assert.Equal(t, map[string]string{"X-Request-Id": "abc"}, rendered.Headers)
+	assert.JSONEq(t, `{"status":"paid"}`, rendered.Body)
+	assert.Equal(t, "http://localhost/orders/{{.PathParams.id}}/events", callback.URL)
+}
+
+func TestCallbackJournalRecordIsBounded(t *testing.T) {
+	journal := NewCallbackJournal(2)
+
+	journal.Record(CallbackAttempt{StubID: "a"})
+	journal.Record(CallbackAttempt{StubID: "b"})
+	journal.Record(CallbackAttempt{StubID: "a"})
+
+	attempts := journal.Find("")
+	require.Len(t, attempts, 2)
+	assert.Equal(t, int64(2), attempts[0].ID)
+	assert.Equal(t, int64(3), attempts[1].ID)
+	assert.Len(t, journal.Find("a"), 1)
+
+	journal.Clear()
+	assert.Empty(t, journal.Find(""))
+}
+
+//nolint:funlen
+func TestServeCallbacks(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		received = append(received, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Order")+" "+string(body))
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	server := NewServer()
+	testServer := httptest.NewServer(server.Router)
+
+	defer testServer.Close()
+
+	ns := server.namespaces.get(DefaultNamespace)
+
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		ID:                 "payments",
+		EndpointID:         EndpointID{Path: "/payments", HTTPMethod: http.MethodPost},
+		ResponseStatusCode: http.StatusAccepted,
+		Callbacks: []Callback{
+			{
+				URL:        receiver.URL + "/hooks/{{.JSON.orderId}}",
+				Headers:    map[string]string{"X-Order": "{{.JSON.orderId}}"},
+				Body:       `{"status":"settled"}`,
+				Delay:      10 * time.Millisecond,
+				Retries:    2,
+				RetryDelay: 10 * time.Millisecond,
+			},
+			{URL: "{{template \"missing\"}}"},
+		},
+	}))
+
+	resp, err := http.Post(testServer.URL+"/payments", "application/json", strings.NewReader(`{"orderId":"o-1"}`))
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	assert.Eventually(t, func() bool {
+		return len(ns.callbacks.Find("payments")) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	attempts := ns.callbacks.Find("payments")
+	require.Len(t, attempts, 3)
+
+	var sent []CallbackAttempt
+
+	for _, attempt := range attempts {
+		if attempt.Callback == 1 {
+			assert.Contains(t, attempt.Error, "unable to render callback")
+
+			continue
+		}
+
+		sent = append(sent, attempt)
+	}
+
+	require.Len(t, sent, 2)
+	assert.Equal(t, 1, sent[0].Attempt)
+	assert.Equal(t, http.StatusInternalServerError, sent[0].StatusCode)
+	assert.Equal(t, 2, sent[1].Attempt)
+	assert.Equal(t, http.StatusAccepted, sent[1].StatusCode)
+	assert.Equal(t, http.MethodPost, sent[1].Method)
+	assert.Equal(t, receiver.URL+"/hooks/o-1", sent[1].URL)
+
+	mu.Lock()
+	assert.Equal(t, []string{
+		`POST /hooks/o-1 o-1 {"status":"settled"}`,
+		`POST /hooks/o-1 o-1 {"status":"settled"}`,
+	}, received)
+	mu.Unlock()
+
+	ns.callbacks.Clear()
+	assert.Empty(t, ns.callbacks.Find(""))
+}
+
+func TestCancelPendingCallbacks(t *testing.T) {
+	var received atomic.Int32
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	journal := NewCallbackJournal(0)
+	sender := newCallbackSender(journal)
+	delayed := []Callback{{URL: receiver.URL, Delay: 50 * time.Millisecond}}
+
+	sender.Fire("delayed", delayed, &TemplateData{})
+	sender.CancelPending()
+
+	sender.Fire("immediate", []Callback{{URL: receiver.URL}}, &TemplateData{})
+
+	assert.Eventually(t, func() bool {
+		return len(journal.Find("immediate")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	sender.Fire("closed", delayed, &TemplateData{})
+	sender.Close()
+	sender.Fire("closed", []Callback{{URL: receiver.URL}}, &TemplateData{})
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, int32(1), received.Load())
+	assert.Empty(t, journal.Find("delayed"))
+	assert.Empty(t, journal.Find("closed"))
+}
+
+func TestResetCancelsPendingCallbacks(t *testing.T) {
+	var received atomic.Int32
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	server := NewServer()
+
+	for _, namespace := range []string{DefaultNamespace, "tenant-a"} {
+		require.NoError(t, server.namespaces.get(namespace).responseManager.AddEndpoint(EndpointConfiguration{
+			EndpointID:         EndpointID{Path: "/orders", HTTPMethod: http.MethodPost},
+			ResponseStatusCode: http.StatusAccepted,
+			Callbacks:          []Callback{{URL: receiver.URL, Delay: 50 * time.Millisecond}},
+		}))
+	}
+
+	send := func(method, path string) int {
+		request := httptest.NewRequest(method, path, nil)
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusAccepted, send(http.MethodPost, "/orders"))
+	require.Equal(t, http.StatusAccepted, send(http.MethodPost, "/namespaces/tenant-a/orders"))
+
+	require.Equal(t, http.StatusOK, send(http.MethodDelete, "/stubserver/responses"))
+	require.Equal(t, http.StatusOK, send(http.MethodDelete, "/stubserver/namespaces/tenant-a"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Zero(t, received.Load())
+}
+
+func newCallbackReceiver(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(receiver.Close)
+
+	return receiver
+}
+
+func TestCallbacksOfHeldOpenStream(t *testing.T) {
+	receiver := newCallbackReceiver(t)
+	server := NewServer()
+	testServer := httptest.NewServer(server.Router)
+
+	defer testServer.Close()
+
+	ns := server.namespaces.get(DefaultNamespace)
+
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		ID:                 "events",
+		EndpointID:         EndpointID{Path: "/events", HTTPMethod: http.MethodGet},
+		ResponseStatusCode: http.StatusOK,
+		Stream:             &Stream{Mode: StreamModeSSE, HoldOpen: true},
+		Callbacks:          []Callback{{URL: receiver.URL}},
+	}))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/events", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(ns.callbacks.Find("events")) == 1
+	}, time.Second, 10*time.Millisecond, "the callback is sent while the stream is still open")
+}
+
+func TestCallbacksAreNotSentForFaults(t *testing.T) {
+	receiver := newCallbackReceiver(t)
+	server := NewServer()
+	testServer := httptest.NewServer(server.Router)
+
+	defer testServer.Close()
+
+	ns := server.namespaces.get(DefaultNamespace)
+
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		ID:                 "payments",
+		EndpointID:         EndpointID{Path: "/payments", HTTPMethod: http.MethodPost},
+		ResponseStatusCode: http.StatusAccepted,
+		Fault:              &Fault{Type: FaultTypeEmptyResponse},
+		Callbacks:          []Callback{{URL: receiver.URL}},
+	}))
+
+	resp, err := http.Post(testServer.URL+"/payments", "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	require.Error(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Empty(t, ns.callbacks.Find("payments"))
+}
+
+func TestCallbacksOfGRPCStream(t *testing.T) {
+	receiver := newCallbackReceiver(t)
+	server := NewServer()
+	require.NoError(t, server.descriptors.Add(ordersDescriptorSet()))
+
+	conn := startGRPCServer(t, server)
+	ns := server.namespaces.get(DefaultNamespace)
+
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		ID:         "watch",
+		EndpointID: EndpointID{Path: watchOrdersMethod, HTTPMethod: http.MethodPost, GRPC: true},
+		GRPC: &GRPC{
+			Messages: []GRPCMessage{{Data: `{"id":"1"}`}, {Delay: time.Minute, Data: `{"id":"2"}`}},
+		},
+		Callbacks: []Callback{{URL: receiver.URL}},
+	}))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, watchOrdersMethod)
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(newMessage(t, server, "orders.v1.WatchOrdersRequest", `{"customer":"acme"}`)))
+	require.NoError(t, stream.CloseSend())
+	require.NoError(t, stream.RecvMsg(newMessage(t, server, "orders.v1.Order", "{}")))
+
+	assert.Eventually(t, func() bool {
+		return len(ns.callbacks.Find("watch")) == 1
+	}, time.Second, 10*time.Millisecond, "the callback is sent after the first message")
+}
@@ -0,0 +1,48 @@
+package stubserver
+
+import "strings"
+
+const defaultMaxLoggedBodyBytes = 1024 * 10
+
+// Config represents the configuration of the stub server. Zero values are replaced by the
+// defaults of DefaultConfig.
+type Config struct {
+	// AdminBasePath is the path under which the admin API is served.
+	AdminBasePath string
+	// JournalSize is the number of requests the request journal of each namespace keeps.
+	JournalSize int
+	// MaxLoggedBodyBytes limits the part of a request body that is logged.
+	MaxLoggedBodyBytes int
+}
+
+// DefaultConfig returns the default configuration of the stub server.
+func DefaultConfig() Config {
+	return Config{
+		AdminBasePath:      BaseURLPath,
+		JournalSize:        defaultJournalSize,
+		MaxLoggedBodyBytes: defaultMaxLoggedBodyBytes,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+
+	c.AdminBasePath = strings.TrimSuffix(c.AdminBasePath, "/")
+	if c.AdminBasePath == "" {
+		c.AdminBasePath = defaults.AdminBasePath
+	}
+
+	if !strings.HasPrefix(c.AdminBasePath, "/") {
+		c.AdminBasePath = "/" + c.AdminBasePath
+	}
+
+	if c.JournalSize <= 0 {
+		c.JournalSize = defaults.JournalSize
+	}
+
+	if c.MaxLoggedBodyBytes <= 0 {
+		c.MaxLoggedBodyBytes = defaults.MaxLoggedBodyBytes
+	}
+
+	return c
+}
@@ -0,0 +1,14 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigWithDefaults(t *testing.T) {
+	assert.Equal(t, DefaultConfig(), Config{}.withDefaults())
+
+	config := Config{AdminBasePath: "admin/", JournalSize: 10, MaxLoggedBodyBytes: 64}.withDefaults()
+	assert.Equal(t, Config{AdminBasePath: "/admin", JournalSize: 10, MaxLoggedBodyBytes: 64}, config)
+}
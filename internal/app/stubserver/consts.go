@@ -3,8 +3,50 @@ package stubserver
 const (
 	// BaseURLPath is the base URL path for the stub server.
 	BaseURLPath = "/stubserver"
+	// NamespacePathPrefix is the path prefix that scopes requests to a namespace, e.g.
+	// "/namespaces/{name}/orders" or "/namespaces/{name}/stubserver/responses".
+	NamespacePathPrefix = "/namespaces"
+	// NamespaceHeader is the request header that scopes requests to a namespace.
+	NamespaceHeader = "X-Stub-Namespace"
+	// NamespacesEndpoint is the endpoint for deleting namespaces.
+	NamespacesEndpoint = "/namespaces"
 	// HealthEndpoint is the health check endpoint.
 	HealthEndpoint = "/health"
 	// ResponsesEndpoint is the endpoint for managing responses.
 	ResponsesEndpoint = "/responses"
+	// ExportResponsesEndpoint is the endpoint for exporting responses as stub definitions.
+	ExportResponsesEndpoint = "/responses/export"
+	// OpenAPIEndpoint is the endpoint for generating responses from an OpenAPI specification.
+	OpenAPIEndpoint = "/openapi"
+	// ContractEndpoint is the endpoint for configuring the OpenAPI contract that requests are validated against.
+	ContractEndpoint = "/contract"
+	// ViolationsEndpoint is the endpoint for listing the requests that violated the OpenAPI contract.
+	ViolationsEndpoint = "/violations"
+	// SequencesEndpoint is the endpoint for managing response sequences.
+	SequencesEndpoint = "/sequences"
+	// ScenariosEndpoint is the endpoint for managing scenarios.
+	ScenariosEndpoint = "/scenarios"
+	// RequestsEndpoint is the endpoint for querying the request journal.
+	RequestsEndpoint = "/requests"
+	// FindRequestsEndpoint is the endpoint for finding requests in the request journal with a matcher.
+	FindRequestsEndpoint = "/requests/find"
+	// UnmatchedEndpoint is the endpoint for listing the requests that matched no stub.
+	UnmatchedEndpoint = "/unmatched"
+	// CAEndpoint is the endpoint for downloading the generated CA certificate.
+	CAEndpoint = "/ca.pem"
+	// ClientCertificatesEndpoint is the endpoint for issuing client certificates with the generated CA.
+	ClientCertificatesEndpoint = "/client-certificates"
+	// WebSocketFramesEndpoint is the endpoint for querying the frames received by WebSocket endpoints.
+	WebSocketFramesEndpoint = "/websocket/frames"
+	// GRPCDescriptorsEndpoint is the endpoint for uploading the FileDescriptorSet of gRPC services.
+	GRPCDescriptorsEndpoint = "/grpc/descriptors"
+	// GRPCReflectionEndpoint is the endpoint for loading the descriptors of gRPC services from an
+	// upstream server with server reflection.
+	GRPCReflectionEndpoint = "/grpc/descriptors/reflection"
+	// GRPCServicesEndpoint is the endpoint for listing the gRPC services that can be stubbed.
+	GRPCServicesEndpoint = "/grpc/services"
+	// CallbacksEndpoint is the endpoint for querying the attempts to send the callbacks of stubs.
+	CallbacksEndpoint = "/callbacks"
+	// ProxyEndpoint is the endpoint for configuring the proxy for unmatched requests.
+	ProxyEndpoint = "/proxy"
 )
@@ -0,0 +1,205 @@
+package stubserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// Violation represents a request that does not conform to the OpenAPI contract.
+type Violation struct {
+	Timestamp time.Time
+	Method    string
+	URL       string
+	// Operation is the method and path template of the operation, empty when no operation matches.
+	Operation string
+	Errors    []string
+}
+
+// ContractValidator validates requests against an OpenAPI contract and keeps the most recent
+// violations in memory.
+type ContractValidator struct {
+	mu         sync.RWMutex
+	doc        *openapi3.T
+	basePath   string
+	reject     bool
+	violations []Violation
+	maxSize    int
+}
+
+// NewContractValidator creates a new, disabled instance of ContractValidator that keeps at most
+// maxSize violations.
+func NewContractValidator(maxSize int) *ContractValidator {
+	if maxSize <= 0 {
+		maxSize = defaultJournalSize
+	}
+
+	return &ContractValidator{violations: make([]Violation, 0), maxSize: maxSize}
+}
+
+// LoadOpenAPIContract validates the requests to the default namespace against the OpenAPI 3
+// document in the JSON or YAML file. When reject is set, violating requests are answered with 400.
+func (s *Server) LoadOpenAPIContract(path string, reject bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read OpenAPI contract: %w", err)
+	}
+
+	err = s.namespaces.get(DefaultNamespace).contract.Configure(data, reject)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Configure enables validation against the OpenAPI 3 document. When reject is set, Validate
+// reports that violating requests must be rejected.
+func (v *ContractValidator) Configure(spec []byte, reject bool) error {
+	doc, err := loadOpenAPISpec(spec)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.doc = doc
+	v.basePath = openAPIBasePath(doc.Servers)
+	v.reject = reject
+
+	return nil
+}
+
+// Disable stops validating requests. The recorded violations are kept.
+func (v *ContractValidator) Disable() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.doc = nil
+	v.basePath = ""
+	v.reject = false
+}
+
+// Validate checks the request, whose body has already been read, against the contract and records
+// a violation when it does not conform. It returns the violation, if any, and whether the request
+// must be rejected.
+func (v *ContractValidator) Validate(r *http.Request, body []byte) (*Violation, bool) {
+	v.mu.RLock()
+	doc, basePath, reject := v.doc, v.basePath, v.reject
+	v.mu.RUnlock()
+
+	if doc == nil {
+		return nil, false
+	}
+
+	route, pathParams, err := findOperation(doc, basePath, r.Method, r.URL.Path)
+
+	violation := Violation{Timestamp: time.Now(), Method: r.Method, URL: r.URL.String()}
+
+	if err == nil {
+		violation.Operation = route.Method + " " + basePath + route.Path
+
+		request := r.Clone(r.Context())
+		request.Body = io.NopCloser(bytes.NewReader(body))
+
+		err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		})
+	}
+
+	if err == nil {
+		return nil, false
+	}
+
+	violation.Errors = validationErrors(err)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.violations) >= v.maxSize {
+		v.violations = v.violations[len(v.violations)-v.maxSize+1:]
+	}
+
+	v.violations = append(v.violations, violation)
+
+	return &violation, reject
+}
+
+// Violations returns the recorded violations, oldest first.
+func (v *ContractValidator) Violations() []Violation {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return slices.Clone(v.violations)
+}
+
+// ClearViolations removes all recorded violations.
+func (v *ContractValidator) ClearViolations() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.violations = make([]Violation, 0)
+}
+
+// findOperation returns the operation of the document with the most specific path template that
+// matches the path, and the values of its path parameters.
+func findOperation(doc *openapi3.T, basePath, method, path string) (*routers.Route, map[string]string, error) {
+	var (
+		matchedPath   string
+		matchedParams map[string]string
+	)
+
+	for _, specPath := range slices.Sorted(maps.Keys(doc.Paths.Map())) {
+		params, ok := matchPath(basePath+specPath, path)
+		if ok && (matchedParams == nil || pathSpecificity(specPath) > pathSpecificity(matchedPath)) {
+			matchedPath, matchedParams = specPath, params
+		}
+	}
+
+	if matchedParams == nil {
+		return nil, nil, fmt.Errorf("no operation matches path %s", path)
+	}
+
+	pathItem := doc.Paths.Value(matchedPath)
+
+	operation := pathItem.GetOperation(method)
+	if operation == nil {
+		return nil, nil, fmt.Errorf("method %s is not allowed for path %s", method, basePath+matchedPath)
+	}
+
+	route := &routers.Route{Spec: doc, Path: matchedPath, PathItem: pathItem, Method: method, Operation: operation}
+
+	return route, matchedParams, nil
+}
+
+func validationErrors(err error) []string {
+	var multiError openapi3.MultiError
+	if !errors.As(err, &multiError) {
+		return []string{err.Error()}
+	}
+
+	messages := make([]string, 0, len(multiError))
+	for _, err := range multiError {
+		messages = append(messages, err.Error())
+	}
+
+	return messages
+}
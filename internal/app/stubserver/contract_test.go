@@ -0,0 +1,192 @@
+package stubserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ordersContract = `
+openapi: 3.0.3
+info:
+  title: Orders
+  version: 1.0.0
+servers:
+  - url: /api
+paths:
+  /orders:
+    get:
+      parameters:
+        - name: status
+          in: query
+          schema:
+            type: string
+            enum: [open, closed]
+      responses:
+        "200":
+          description: The orders
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [product]
+              properties:
+                product:
+                  type: string
+                quantity:
+                  type: integer
+                  minimum: 1
+      responses:
+        "201":
+          description: The created order
+  /orders/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: The order
+`
+
+//nolint:funlen
+func TestContractValidator(t *testing.T) {
+	tests := []struct {
+		name              string
+		method            string
+		target            string
+		body              string
+		expectedOperation string
+		errorsContain     []string
+	}{
+		{name: "valid query", method: http.MethodGet, target: "/api/orders?status=open"},
+		{name: "valid body", method: http.MethodPost, target: "/api/orders", body: `{"product":"book","quantity":2}`},
+		{name: "valid path parameter", method: http.MethodGet, target: "/api/orders/42"},
+		{
+			name:              "invalid query",
+			method:            http.MethodGet,
+			target:            "/api/orders?status=lost",
+			expectedOperation: "GET /api/orders",
+			errorsContain:     []string{`parameter "status" in query has an error`},
+		},
+		{
+			name:              "invalid body",
+			method:            http.MethodPost,
+			target:            "/api/orders",
+			body:              `{"quantity":0}`,
+			expectedOperation: "POST /api/orders",
+			errorsContain:     []string{"request body has an error"},
+		},
+		{
+			name:              "invalid path parameter",
+			method:            http.MethodGet,
+			target:            "/api/orders/latest",
+			expectedOperation: "GET /api/orders/{id}",
+			errorsContain:     []string{`parameter "id" in path has an error`},
+		},
+		{
+			name:          "unknown path",
+			method:        http.MethodGet,
+			target:        "/api/customers",
+			errorsContain: []string{"no operation matches path /api/customers"},
+		},
+		{
+			name:          "method not allowed",
+			method:        http.MethodDelete,
+			target:        "/api/orders",
+			errorsContain: []string{"method DELETE is not allowed for path /api/orders"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewContractValidator(10)
+			require.NoError(t, validator.Configure([]byte(ordersContract), true))
+
+			request := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				request.Header.Set(contentTypeHeader, "application/json")
+			}
+
+			violation, reject := validator.Validate(request, []byte(tt.body))
+			if tt.errorsContain == nil {
+				assert.Nil(t, violation)
+				assert.False(t, reject)
+				assert.Empty(t, validator.Violations())
+
+				return
+			}
+
+			require.NotNil(t, violation)
+			assert.True(t, reject)
+			assert.Equal(t, tt.expectedOperation, violation.Operation)
+			assert.Equal(t, tt.target, violation.URL)
+
+			for i, expected := range tt.errorsContain {
+				assert.Contains(t, violation.Errors[i], expected)
+			}
+
+			assert.Equal(t, []Violation{*violation}, validator.Violations())
+		})
+	}
+}
+
+func TestContractValidatorLifecycle(t *testing.T) {
+	validator := NewContractValidator(1)
+
+	violation, _ := validator.Validate(httptest.NewRequest(http.MethodGet, "/unknown", nil), nil)
+	assert.Nil(t, violation)
+
+	err := validator.Configure([]byte("openapi: ["), false)
+	require.ErrorContains(t, err, "invalid OpenAPI spec")
+
+	require.NoError(t, validator.Configure([]byte(ordersContract), false))
+
+	for _, target := range []string{"/unknown", "/api/orders?status=lost"} {
+		violation, reject := validator.Validate(httptest.NewRequest(http.MethodGet, target, nil), nil)
+		require.NotNil(t, violation)
+		assert.False(t, reject)
+	}
+
+	violations := validator.Violations()
+	require.Len(t, violations, 1)
+	assert.Equal(t, "/api/orders?status=lost", violations[0].URL)
+
+	validator.ClearViolations()
+	assert.Empty(t, validator.Violations())
+
+	validator.Disable()
+
+	violation, _ = validator.Validate(httptest.NewRequest(http.MethodGet, "/unknown", nil), nil)
+	assert.Nil(t, violation)
+}
+
+func TestLoadOpenAPIContract(t *testing.T) {
+	dir := t.TempDir()
+
+	writeDefinitionFile(t, dir, "orders.yaml", ordersContract)
+
+	server := NewServer()
+
+	err := server.LoadOpenAPIContract(filepath.Join(dir, "orders.yaml"), true)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/orders?status=lost", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "request violates the OpenAPI contract")
+
+	err = server.LoadOpenAPIContract(filepath.Join(dir, "missing.yaml"), true)
+	assert.ErrorContains(t, err, "unable to read OpenAPI contract")
+}
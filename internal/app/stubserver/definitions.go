@@ -0,0 +1,95 @@
+package stubserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/schubergphilis/mcvs-integrationtest-services/pkg/stubserver/models"
+)
+
+// definitionFileExtensions are the extensions of the files that LoadStubDefinitions reads. YAML is
+// a superset of JSON, so every file is parsed as YAML.
+var definitionFileExtensions = []string{".json", ".yaml", ".yml"}
+
+// LoadStubDefinitions adds the endpoints defined in the JSON and YAML files in the directory to the
+// default namespace, in alphabetical order of the file names. A file contains a single endpoint
+// request or a list of them.
+func (s *Server) LoadStubDefinitions(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("unable to read stub definitions directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !slices.Contains(definitionFileExtensions, strings.ToLower(filepath.Ext(entry.Name()))) {
+			continue
+		}
+
+		err = s.namespaces.get(DefaultNamespace).loadStubDefinitionFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ns *namespace) loadStubDefinitionFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read stub definition file: %w", err)
+	}
+
+	requests, err := parseStubDefinitions(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, request := range requests {
+		err = ns.responseManager.AddEndpoint(toEndpointConfiguration(&request))
+		if err != nil {
+			return fmt.Errorf("%s: endpoint %d: %w", path, i, err)
+		}
+	}
+
+	return nil
+}
+
+func parseStubDefinitions(data []byte) ([]models.EndpointRequest, error) {
+	var requests []models.EndpointRequest
+
+	err := yaml.UnmarshalWithOptions(data, &requests, yaml.Strict())
+	if err == nil {
+		return requests, nil
+	}
+
+	var request models.EndpointRequest
+
+	singleErr := yaml.UnmarshalWithOptions(data, &request, yaml.Strict())
+	if singleErr != nil {
+		return nil, fmt.Errorf("invalid stub definitions: %w", singleErr)
+	}
+
+	return []models.EndpointRequest{request}, nil
+}
+
+// exportStubDefinitions returns the configuration of every endpoint in the shape of the request
+// that adds it, sorted by endpoint ID.
+func (ns *namespace) exportStubDefinitions() []models.EndpointRequest {
+	configs := ns.responseManager.GetAllEndpointConfigurations()
+
+	slices.SortFunc(configs, func(a, b EndpointConfiguration) int {
+		return strings.Compare(GetID(&a.EndpointID), GetID(&b.EndpointID))
+	})
+
+	requests := make([]models.EndpointRequest, 0, len(configs))
+	for _, config := range configs {
+		requests = append(requests, toEndpointRequest(&config))
+	}
+
+	return requests
+}
@@ -0,0 +1,81 @@
+package stubserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDefinitionFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+	require.NoError(t, err)
+}
+
+func TestLoadStubDefinitions(t *testing.T) {
+	dir := t.TempDir()
+
+	writeDefinitionFile(t, dir, "orders.json", `[
+		{"path": "/orders", "httpMethod": "GET", "responseBody": "[]", "responseStatusCode": 200},
+		{"path": "/orders", "httpMethod": "POST", "responseBody": "{}", "responseStatusCode": 201}
+	]`)
+	writeDefinitionFile(t, dir, "users.yaml", `
+path: /users/{id}
+httpMethod: GET
+headerMatchers:
+  Authorization:
+    matchType: prefix
+    value: Bearer
+responseBody: '{"id":"1"}'
+responseStatusCode: 200
+`)
+	writeDefinitionFile(t, dir, "README.md", "not a stub definition")
+
+	server := NewServer()
+
+	err := server.LoadStubDefinitions(dir)
+	require.NoError(t, err)
+
+	definitions := server.namespaces.get(DefaultNamespace).exportStubDefinitions()
+	require.Len(t, definitions, 3)
+	assert.Equal(t, "/orders", definitions[0].Path)
+	assert.Equal(t, "/users/{id}", definitions[2].Path)
+	assert.Equal(t, "prefix", definitions[2].HeaderMatchers["Authorization"].MatchType)
+}
+
+func TestLoadStubDefinitionsWithInvalidFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		errorContains string
+	}{
+		{
+			name:          "unknown field",
+			content:       `{"path": "/orders", "httpMethod": "GET", "responseBdy": "[]"}`,
+			errorContains: "invalid stub definitions",
+		},
+		{
+			name:          "invalid endpoint",
+			content:       `[{"path": "/orders", "httpMethod": "FETCH", "responseBody": "[]"}]`,
+			errorContains: "endpoint 0: invalid HTTP method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeDefinitionFile(t, dir, "stubs.json", tt.content)
+
+			err := NewServer().LoadStubDefinitions(dir)
+			assert.ErrorContains(t, err, "stubs.json")
+			assert.ErrorContains(t, err, tt.errorContains)
+		})
+	}
+
+	err := NewServer().LoadStubDefinitions(filepath.Join(t.TempDir(), "missing"))
+	assert.ErrorContains(t, err, "unable to read stub definitions directory")
+}
@@ -0,0 +1,206 @@
+package stubserver
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FaultType represents the way serving a response of an endpoint fails.
+type FaultType string
+
+const (
+	// FaultTypeConnectionReset resets the connection without sending a response.
+	FaultTypeConnectionReset FaultType = "connectionReset"
+	// FaultTypeEmptyResponse closes the connection without sending a response.
+	FaultTypeEmptyResponse FaultType = "emptyResponse"
+	// FaultTypeMalformedChunk sends the response headers followed by an invalid chunked body.
+	FaultTypeMalformedChunk FaultType = "malformedChunk"
+	// FaultTypeTruncatedBody sends the response headers and closes the connection halfway the body.
+	FaultTypeTruncatedBody FaultType = "truncatedBody"
+)
+
+const malformedChunk = "lorem ipsum dolor sit amet\r\n"
+
+// Fault describes the latency and failures that are injected when an endpoint is served.
+type Fault struct {
+	// FixedDelay is added to every response.
+	FixedDelay time.Duration
+	// RandomDelayMin and RandomDelayMax define a range from which an additional delay is drawn.
+	RandomDelayMin time.Duration
+	RandomDelayMax time.Duration
+
+	// Type optionally makes serving the response fail.
+	Type FaultType
+	// Probability is the share of calls, between 0 and 1, that fail with Type. Defaults to 1 when
+	// nil, so that an explicit 0 turns the failure off.
+	Probability *float64
+}
+
+func validateFault(ep *EndpointConfiguration) error {
+	fault := ep.Fault
+	if fault == nil {
+		return nil
+	}
+
+	if fault.FixedDelay < 0 || fault.RandomDelayMin < 0 || fault.RandomDelayMax < 0 {
+		return fmt.Errorf("fault delays must not be negative")
+	}
+
+	if fault.RandomDelayMin > fault.RandomDelayMax {
+		return fmt.Errorf("invalid random delay range: %s > %s", fault.RandomDelayMin, fault.RandomDelayMax)
+	}
+
+	switch fault.Type {
+	case "", FaultTypeConnectionReset, FaultTypeEmptyResponse, FaultTypeMalformedChunk, FaultTypeTruncatedBody:
+	default:
+		return fmt.Errorf("invalid fault type: %s", fault.Type)
+	}
+
+	if fault.Probability != nil && (*fault.Probability < 0 || *fault.Probability > 1) {
+		return fmt.Errorf("fault probability must be between 0 and 1: %v", *fault.Probability)
+	}
+
+	if fault.Type == FaultTypeTruncatedBody && !hasResponseBodies(ep) {
+		return fmt.Errorf("fault %s requires a response body", fault.Type)
+	}
+
+	return nil
+}
+
+// hasResponseBodies reports whether every response of the endpoint has a body.
+func hasResponseBodies(ep *EndpointConfiguration) bool {
+	if len(ep.Responses) == 0 {
+		return ep.ResponseBody != "" || ep.ResponseBodyBase64 != "" || ep.ResponseBodyFile != ""
+	}
+
+	for _, response := range ep.Responses {
+		if response.Body == "" && response.BodyBase64 == "" && response.BodyFile == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Delay returns the delay to add to a response, drawing the random part from its range.
+func (f *Fault) Delay() time.Duration {
+	delay := f.FixedDelay
+
+	if f.RandomDelayMax > f.RandomDelayMin {
+		//nolint:gosec // the delay is not security sensitive
+		delay += f.RandomDelayMin + rand.N(f.RandomDelayMax-f.RandomDelayMin+1)
+	} else {
+		delay += f.RandomDelayMin
+	}
+
+	return delay
+}
+
+// ShouldFail reports whether the current call fails, taking the probability into account.
+func (f *Fault) ShouldFail() bool {
+	if f.Type == "" {
+		return false
+	}
+
+	if f.Probability == nil {
+		return true
+	}
+
+	//nolint:gosec // the probability is not security sensitive
+	return rand.Float64() < *f.Probability
+}
+
+// sleep waits for the delay, returning early when the context is done.
+func sleep(ctx context.Context, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// writeFault takes over the connection and fails serving the response as described by the fault type.
+func writeFault(w http.ResponseWriter, faultType FaultType, response *Response) error {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return fmt.Errorf("connection does not support fault %s", faultType)
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return fmt.Errorf("unable to take over connection: %w", err)
+	}
+
+	if faultType == FaultTypeConnectionReset {
+		return resetConnection(conn)
+	}
+
+	defer closeConnection(conn)
+
+	switch faultType {
+	case FaultTypeConnectionReset, FaultTypeEmptyResponse:
+	case FaultTypeMalformedChunk:
+		writeStatusLine(buf, response, "Transfer-Encoding", "chunked")
+		_, _ = buf.WriteString(malformedChunk)
+	case FaultTypeTruncatedBody:
+		writeStatusLine(buf, response, "Content-Length", strconv.Itoa(len(response.Body)))
+		_, _ = buf.WriteString(response.Body[:len(response.Body)/2])
+	}
+
+	return buf.Flush()
+}
+
+// resetConnection closes the connection with a TCP reset. A TLS connection is reset underneath
+// TLS, so the client does not receive a close notification first.
+func resetConnection(conn net.Conn) error {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	defer closeConnection(conn)
+
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return fmt.Errorf("connection does not support fault %s", FaultTypeConnectionReset)
+	}
+
+	// A zero linger time makes closing the connection send a reset.
+	return tcpConn.SetLinger(0)
+}
+
+func writeStatusLine(buf *bufio.ReadWriter, response *Response, name, value string) {
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	_, _ = fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", statusCode, http.StatusText(statusCode))
+
+	for key, headerValue := range response.Headers {
+		_, _ = fmt.Fprintf(buf, "%s: %s\r\n", key, headerValue)
+	}
+
+	_, _ = fmt.Fprintf(buf, "%s: %s\r\nConnection: close\r\n\r\n", name, value)
+}
+
+func closeConnection(conn net.Conn) {
+	err := conn.Close()
+	if err != nil {
+		log.WithError(err).Error("unable to close connection")
+	}
+}
@@ -0,0 +1,112 @@
+package stubserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFault(t *testing.T) {
+	tests := []struct {
+		name          string
+		fault         *Fault
+		responses     []Response
+		errorContains string
+	}{
+		{name: "no fault", fault: nil},
+		{name: "delays only", fault: &Fault{FixedDelay: time.Second, RandomDelayMin: time.Second, RandomDelayMax: 2 * time.Second}},
+		{name: "fault with probability", fault: &Fault{Type: FaultTypeTruncatedBody, Probability: probability(0.5)}},
+		{name: "fault turned off", fault: &Fault{Type: FaultTypeEmptyResponse, Probability: probability(0)}},
+		{name: "negative delay", fault: &Fault{FixedDelay: -time.Second}, errorContains: "must not be negative"},
+		{name: "invalid delay range", fault: &Fault{RandomDelayMin: 2 * time.Second, RandomDelayMax: time.Second}, errorContains: "invalid random delay range"},
+		{name: "invalid type", fault: &Fault{Type: "timeout"}, errorContains: "invalid fault type"},
+		{name: "invalid probability", fault: &Fault{Type: FaultTypeEmptyResponse, Probability: probability(1.5)}, errorContains: "between 0 and 1"},
+		{
+			name:          "truncated empty body in sequence",
+			fault:         &Fault{Type: FaultTypeTruncatedBody},
+			responses:     []Response{{Body: "ok"}, {StatusCode: 204}},
+			errorContains: "fault truncatedBody requires a response body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFault(&EndpointConfiguration{ResponseBody: "{}", Responses: tt.responses, Fault: tt.fault})
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+
+	err := validateFault(&EndpointConfiguration{Fault: &Fault{Type: FaultTypeTruncatedBody}})
+	assert.ErrorContains(t, err, "fault truncatedBody requires a response body")
+}
+
+func TestFaultDelay(t *testing.T) {
+	fault := Fault{FixedDelay: 100 * time.Millisecond, RandomDelayMin: 10 * time.Millisecond, RandomDelayMax: 20 * time.Millisecond}
+
+	for range 100 {
+		delay := fault.Delay()
+		assert.GreaterOrEqual(t, delay, 110*time.Millisecond)
+		assert.LessOrEqual(t, delay, 120*time.Millisecond)
+	}
+
+	fault = Fault{RandomDelayMin: 10 * time.Millisecond, RandomDelayMax: 10 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, fault.Delay())
+}
+
+func TestFaultShouldFail(t *testing.T) {
+	assert.False(t, (&Fault{FixedDelay: time.Second}).ShouldFail())
+	assert.True(t, (&Fault{Type: FaultTypeEmptyResponse}).ShouldFail())
+	assert.True(t, (&Fault{Type: FaultTypeEmptyResponse, Probability: probability(1)}).ShouldFail())
+
+	off := Fault{Type: FaultTypeEmptyResponse, Probability: probability(0)}
+	for range 100 {
+		assert.False(t, off.ShouldFail())
+	}
+
+	fault := Fault{Type: FaultTypeEmptyResponse, Probability: probability(0.5)}
+
+	failures := 0
+
+	for range 1000 {
+		if fault.ShouldFail() {
+			failures++
+		}
+	}
+
+	assert.InDelta(t, 500, failures, 100)
+}
+
+func probability(value float64) *float64 {
+	return &value
+}
+
+func TestConnectionResetFaultOverTLS(t *testing.T) {
+	server := NewServer()
+	testServer := httptest.NewTLSServer(server.Router)
+
+	defer testServer.Close()
+
+	require.NoError(t, server.namespaces.get(DefaultNamespace).responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID:         EndpointID{Path: "/orders", HTTPMethod: http.MethodGet},
+		ResponseBody:       "orders",
+		ResponseStatusCode: http.StatusOK,
+		Fault:              &Fault{Type: FaultTypeConnectionReset},
+	}))
+
+	resp, err := testServer.Client().Get(testServer.URL + "/orders")
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+}
@@ -0,0 +1,310 @@
+package stubserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPC describes the status, trailers and messages of an endpoint that stubs a gRPC method. The
+// response body of the endpoint is the JSON representation of the response message of a unary
+// method.
+type GRPC struct {
+	// Code is the status code of the call, like "NOT_FOUND" or "5". Defaults to OK. A unary method
+	// sends no response message when the code is not OK, a server streaming method sends the status
+	// after its messages.
+	Code    string
+	Message string
+	// Trailers are sent as trailing metadata. The response headers are sent as header metadata.
+	Trailers map[string]string
+	// Messages are the JSON response messages of a server streaming method. When empty, the
+	// response body is sent as the only message.
+	Messages []GRPCMessage
+}
+
+// GRPCMessage represents a response message of a server streaming method that is sent after the delay.
+type GRPCMessage struct {
+	Delay time.Duration
+	Data  string
+}
+
+// isFullMethodName reports whether the path is a full gRPC method name like "/package.Service/Method".
+func isFullMethodName(path string) bool {
+	service, method, found := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	return strings.HasPrefix(path, "/") && found && service != "" && method != "" && !strings.Contains(method, "/")
+}
+
+// parseGRPCCode parses a status code name like "NOT_FOUND" or a status code number. An empty
+// code is OK.
+func parseGRPCCode(code string) (codes.Code, error) {
+	if code == "" {
+		return codes.OK, nil
+	}
+
+	var result codes.Code
+
+	err := result.UnmarshalJSON([]byte(code))
+	if err != nil {
+		err = result.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(code))))
+		if err != nil {
+			return codes.OK, fmt.Errorf("invalid gRPC status code: %s", code)
+		}
+	}
+
+	return result, nil
+}
+
+func validateGRPC(ep *EndpointConfiguration) error {
+	if (ep.GRPC != nil) != ep.EndpointID.GRPC {
+		return fmt.Errorf("a gRPC endpoint requires a gRPC response")
+	}
+
+	grpcResponse := ep.GRPC
+	if grpcResponse == nil {
+		return nil
+	}
+
+	if ep.EndpointID.HTTPMethod != http.MethodPost && ep.EndpointID.HTTPMethod != AnyHTTPMethod {
+		return fmt.Errorf("a gRPC endpoint must use the POST method")
+	}
+
+	if ep.EndpointID.PathMatchType == "" && !isFullMethodName(ep.EndpointID.Path) {
+		return fmt.Errorf("the path of a gRPC endpoint must be a full method name like /package.Service/Method: %s", ep.EndpointID.Path)
+	}
+
+	if ep.ResponseBodyBase64 != "" || ep.ResponseBodyFile != "" || ep.Stream != nil || ep.WebSocket != nil {
+		return fmt.Errorf("a gRPC response cannot be combined with a binary response body, stream or WebSocket conversation")
+	}
+
+	for i, response := range ep.Responses {
+		if response.BodyBase64 != "" || response.BodyFile != "" {
+			return fmt.Errorf("response %d: a gRPC response body must be JSON", i)
+		}
+	}
+
+	_, err := parseGRPCCode(grpcResponse.Code)
+	if err != nil {
+		return err
+	}
+
+	for i, message := range grpcResponse.Messages {
+		if message.Delay < 0 {
+			return fmt.Errorf("gRPC message %d: delay must not be negative", i)
+		}
+	}
+
+	return nil
+}
+
+// GRPCServer returns a gRPC server that serves the gRPC endpoints of the stub server. Calls select
+// a namespace with the namespace header as metadata. The server supports server reflection for the
+// service descriptors that were loaded.
+func (s *Server) GRPCServer() *grpc.Server {
+	server := grpc.NewServer(grpc.UnknownServiceHandler(s.serveGRPC))
+
+	options := reflection.ServerOptions{Services: s.descriptors, DescriptorResolver: s.descriptors}
+	reflectionv1.RegisterServerReflectionServer(server, reflection.NewServerV1(options))
+	reflectionv1alpha.RegisterServerReflectionServer(server, reflection.NewServer(options))
+
+	return server
+}
+
+// serveGRPC handles every call of the gRPC server. Client and bidirectional streaming methods are
+// not supported.
+func (s *Server) serveGRPC(_ any, stream grpc.ServerStream) error {
+	fullMethod, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "unable to determine the method of the call")
+	}
+
+	method, err := s.descriptors.findMethod(fullMethod)
+	if err != nil {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+
+	if method.IsStreamingClient() {
+		return status.Errorf(codes.Unimplemented, "client streaming methods are not supported: %s", fullMethod)
+	}
+
+	request := dynamicpb.NewMessage(method.Input())
+
+	err = stream.RecvMsg(request)
+	if err != nil {
+		return err
+	}
+
+	body, err := protojson.Marshal(request)
+	if err != nil {
+		return status.Errorf(codes.Internal, "unable to convert the request message to JSON: %v", err)
+	}
+
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	headers := make(http.Header, len(md))
+
+	for key, values := range md {
+		for _, value := range values {
+			headers.Add(key, value)
+		}
+	}
+
+	log.WithFields(log.Fields{"method": fullMethod, "body": string(body[:min(len(body), s.config.MaxLoggedBodyBytes)])}).Info("incoming gRPC call")
+
+	return s.handleGRPCCall(stream, method, fullMethod, headers, body)
+}
+
+// handleGRPCCall serves the endpoint that matches the call and records the call in the journal.
+func (s *Server) handleGRPCCall(stream grpc.ServerStream, method protoreflect.MethodDescriptor, fullMethod string, headers http.Header, body []byte) error {
+	ns := s.namespaces.get(grpcNamespaceName(headers))
+
+	endpointID := EndpointID{
+		Path:           fullMethod,
+		HTTPMethod:     http.MethodPost,
+		HeadersToMatch: firstValues(headers),
+		Headers:        headers,
+		GRPC:           true,
+		Body:           body,
+	}
+
+	journalEntry := JournalEntry{
+		Timestamp:   time.Now(),
+		Method:      http.MethodPost,
+		URL:         fullMethod,
+		Path:        fullMethod,
+		QueryParams: url.Values{},
+		Headers:     headers,
+		Body:        string(body),
+	}
+
+	result, err := ns.responseManager.ServeEndpoint(&endpointID)
+	if err != nil {
+		journalEntry.NearMisses = ns.responseManager.NearMisses(&endpointID)
+		ns.journal.Record(journalEntry)
+
+		log.WithError(err).WithFields(log.Fields{"method": fullMethod, "nearMisses": len(journalEntry.NearMisses)}).Error("gRPC endpoint not found")
+
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+
+	journalEntry.MatchedEndpointID = result.ID
+	ns.journal.Record(journalEntry)
+
+	response := result.Response
+
+	if result.ResponseTemplating {
+		response, err = renderResponse(response, NewTemplateData(&endpointID, result.PathParams))
+		if err != nil {
+			return status.Errorf(codes.Internal, "unable to render response template: %v", err)
+		}
+	}
+
+	if result.Fault != nil {
+		sleep(stream.Context(), result.Fault.Delay())
+
+		if result.Fault.ShouldFail() {
+			return status.Errorf(codes.Unavailable, "injected fault: %s", result.Fault.Type)
+		}
+	}
+
+	fireCallbacks := func() {
+		ns.callbackSender.Fire(result.ID, result.Callbacks, NewTemplateData(&endpointID, result.PathParams))
+	}
+
+	return writeGRPCResponse(stream, method, result.GRPC, &response, fireCallbacks)
+}
+
+// grpcNamespaceName returns the namespace selected by the namespace header of the call metadata,
+// falling back to the default namespace.
+func grpcNamespaceName(headers http.Header) string {
+	if name := strings.TrimSpace(headers.Get(NamespaceHeader)); name != "" {
+		return name
+	}
+
+	return DefaultNamespace
+}
+
+// writeGRPCResponse sends the header metadata, the response messages and the trailers of the call,
+// and returns the status of the call. started is called after the first message was sent or, when
+// there are no messages, before the status is returned.
+func writeGRPCResponse(stream grpc.ServerStream, method protoreflect.MethodDescriptor, grpcResponse *GRPC, response *Response, started func()) error {
+	code, err := parseGRPCCode(grpcResponse.Code)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	if len(response.Headers) > 0 {
+		err = stream.SetHeader(metadata.New(response.Headers))
+		if err != nil {
+			return err
+		}
+	}
+
+	stream.SetTrailer(metadata.New(grpcResponse.Trailers))
+
+	messages := []GRPCMessage{{Data: response.Body}}
+
+	switch {
+	case !method.IsStreamingServer() && code != codes.OK:
+		messages = nil
+	case !method.IsStreamingServer():
+	case len(grpcResponse.Messages) > 0:
+		messages = grpcResponse.Messages
+	case response.Body == "":
+		messages = nil
+	}
+
+	if len(messages) == 0 {
+		started()
+	}
+
+	for i, message := range messages {
+		err = sendGRPCMessage(stream, method, &message)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			started()
+		}
+	}
+
+	return status.Error(code, grpcResponse.Message)
+}
+
+// sendGRPCMessage sends a response message after its delay. An empty message is sent when the
+// data is empty.
+func sendGRPCMessage(stream grpc.ServerStream, method protoreflect.MethodDescriptor, message *GRPCMessage) error {
+	ctx := stream.Context()
+
+	sleep(ctx, message.Delay)
+
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	response := dynamicpb.NewMessage(method.Output())
+
+	if message.Data != "" {
+		err := protojson.Unmarshal([]byte(message.Data), response)
+		if err != nil {
+			return status.Errorf(codes.Internal, "invalid response message for %s: %v", method.FullName(), err)
+		}
+	}
+
+	return stream.SendMsg(response)
+}
@@ -0,0 +1,386 @@
+package stubserver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/schubergphilis/mcvs-integrationtest-services/pkg/stubserver/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// The well-known types are linked in, so descriptor sets do not need to include them.
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// reflectionServicePrefix is the package of the server reflection services, which are not stubbed.
+const reflectionServicePrefix = "grpc.reflection."
+
+// DescriptorRegistry keeps the protobuf descriptors of the gRPC services that can be stubbed.
+// Descriptors are shared by every namespace.
+type DescriptorRegistry struct {
+	mu     sync.RWMutex
+	protos map[string]*descriptorpb.FileDescriptorProto
+	files  *protoregistry.Files
+}
+
+// NewDescriptorRegistry creates a new instance of DescriptorRegistry without descriptors.
+func NewDescriptorRegistry() *DescriptorRegistry {
+	return &DescriptorRegistry{
+		protos: make(map[string]*descriptorpb.FileDescriptorProto),
+		files:  new(protoregistry.Files),
+	}
+}
+
+// Add adds the files of the descriptor set, replacing files with the same name. Dependencies that
+// are not part of the set must have been added before, or be well-known types. The registry is left
+// untouched when the files cannot be resolved.
+func (r *DescriptorRegistry) Add(set *descriptorpb.FileDescriptorSet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	protos := maps.Clone(r.protos)
+	for _, file := range set.GetFile() {
+		protos[file.GetName()] = file
+	}
+
+	addWellKnownDependencies(protos)
+
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: slices.Collect(maps.Values(protos))})
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set: %w", err)
+	}
+
+	r.protos = protos
+	r.files = files
+
+	return nil
+}
+
+// addWellKnownDependencies adds the dependencies that are missing from the files but are linked
+// into the binary, which are the well-known types.
+func addWellKnownDependencies(protos map[string]*descriptorpb.FileDescriptorProto) {
+	pending := slices.Collect(maps.Keys(protos))
+
+	for len(pending) > 0 {
+		file := protos[pending[0]]
+		pending = pending[1:]
+
+		for _, dependency := range file.GetDependency() {
+			if _, exists := protos[dependency]; exists {
+				continue
+			}
+
+			descriptor, err := protoregistry.GlobalFiles.FindFileByPath(dependency)
+			if err != nil {
+				continue
+			}
+
+			protos[dependency] = protodesc.ToFileDescriptorProto(descriptor)
+			pending = append(pending, dependency)
+		}
+	}
+}
+
+// Services returns the full method names of every service, by the full name of the service.
+func (r *DescriptorRegistry) Services() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	services := make(map[string][]string)
+
+	r.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		for i := range file.Services().Len() {
+			service := file.Services().Get(i)
+
+			methods := make([]string, 0, service.Methods().Len())
+			for j := range service.Methods().Len() {
+				methods = append(methods, fmt.Sprintf("/%s/%s", service.FullName(), service.Methods().Get(j).Name()))
+			}
+
+			services[string(service.FullName())] = methods
+		}
+
+		return true
+	})
+
+	return services
+}
+
+// findMethod returns the descriptor of a method by its full method name, like "/package.Service/Method".
+func (r *DescriptorRegistry) findMethod(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	serviceName, methodName, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+
+	descriptor, err := r.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service: %s", serviceName)
+	}
+
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("unknown service: %s", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("unknown method: %s", fullMethod)
+	}
+
+	return method, nil
+}
+
+// FindFileByPath looks up a file by its path, for server reflection.
+func (r *DescriptorRegistry) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.files.FindFileByPath(path)
+}
+
+// FindDescriptorByName looks up a descriptor by its full name, for server reflection.
+func (r *DescriptorRegistry) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.files.FindDescriptorByName(name)
+}
+
+// GetServiceInfo returns the services that server reflection advertises. Only the names are set.
+func (r *DescriptorRegistry) GetServiceInfo() map[string]grpc.ServiceInfo {
+	services := r.Services()
+
+	info := make(map[string]grpc.ServiceInfo, len(services))
+	for name := range services {
+		info[name] = grpc.ServiceInfo{}
+	}
+
+	return info
+}
+
+// LoadDescriptorSet adds the services of a binary FileDescriptorSet file, as written by
+// "protoc --include_imports --descriptor_set_out", to the gRPC server.
+func (s *Server) LoadDescriptorSet(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read descriptor set: %w", err)
+	}
+
+	err = s.addDescriptorSet(data)
+	if err != nil {
+		return fmt.Errorf("unable to load descriptor set %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (s *Server) addDescriptorSet(data []byte) error {
+	var set descriptorpb.FileDescriptorSet
+
+	err := proto.Unmarshal(data, &set)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set: %w", err)
+	}
+
+	return s.descriptors.Add(&set)
+}
+
+// LoadDescriptorsFromReflection adds the services of an upstream gRPC server, which is reached
+// without TLS, using its server reflection service.
+func (s *Server) LoadDescriptorsFromReflection(ctx context.Context, target string) error {
+	set, err := fetchDescriptorSet(ctx, target)
+	if err != nil {
+		return fmt.Errorf("unable to load descriptors from %s: %w", target, err)
+	}
+
+	return s.descriptors.Add(set)
+}
+
+// uploadDescriptorSet adds the services of the binary FileDescriptorSet in the request body.
+func (s *Server) uploadDescriptorSet(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+
+		return
+	}
+
+	err = s.addDescriptorSet(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	s.getGRPCServices(c)
+}
+
+func (s *Server) loadDescriptorsFromReflection(c *gin.Context) {
+	var request models.GRPCReflectionRequest
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil || request.Target == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+
+		return
+	}
+
+	err = s.LoadDescriptorsFromReflection(c.Request.Context(), request.Target)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	s.getGRPCServices(c)
+}
+
+func (s *Server) getGRPCServices(c *gin.Context) {
+	services := s.descriptors.Services()
+
+	response := models.GRPCServiceListResponse{Services: make([]models.GRPCService, 0, len(services))}
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		response.Services = append(response.Services, models.GRPCService{Name: name, Methods: services[name]})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// fetchDescriptorSet retrieves the files that define the services of a gRPC server, and their
+// dependencies, from its server reflection service.
+func fetchDescriptorSet(ctx context.Context, target string) (*descriptorpb.FileDescriptorSet, error) {
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create gRPC client: %w", err)
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := reflectionv1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start server reflection: %w", err)
+	}
+
+	response, err := sendReflectionRequest(stream, &reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+
+	for _, service := range response.GetListServicesResponse().GetService() {
+		if strings.HasPrefix(service.GetName(), reflectionServicePrefix) {
+			continue
+		}
+
+		response, err = sendReflectionRequest(stream, &reflectionv1.ServerReflectionRequest{
+			MessageRequest: &reflectionv1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service.GetName()},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = collectFiles(protos, response)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = fetchDependencies(stream, protos)
+	if err != nil {
+		return nil, err
+	}
+
+	return &descriptorpb.FileDescriptorSet{File: slices.Collect(maps.Values(protos))}, nil
+}
+
+// fetchDependencies retrieves the dependencies of the files that the server did not send along.
+func fetchDependencies(stream reflectionv1.ServerReflection_ServerReflectionInfoClient, protos map[string]*descriptorpb.FileDescriptorProto) error {
+	pending := slices.Collect(maps.Keys(protos))
+
+	for len(pending) > 0 {
+		file := protos[pending[0]]
+		pending = pending[1:]
+
+		for _, dependency := range file.GetDependency() {
+			if _, exists := protos[dependency]; exists {
+				continue
+			}
+
+			response, err := sendReflectionRequest(stream, &reflectionv1.ServerReflectionRequest{
+				MessageRequest: &reflectionv1.ServerReflectionRequest_FileByFilename{FileByFilename: dependency},
+			})
+			if err != nil {
+				return err
+			}
+
+			err = collectFiles(protos, response)
+			if err != nil {
+				return err
+			}
+
+			if _, exists := protos[dependency]; !exists {
+				return fmt.Errorf("server reflection did not return file %s", dependency)
+			}
+
+			pending = append(pending, dependency)
+		}
+	}
+
+	return nil
+}
+
+func sendReflectionRequest(stream reflectionv1.ServerReflection_ServerReflectionInfoClient, request *reflectionv1.ServerReflectionRequest) (*reflectionv1.ServerReflectionResponse, error) {
+	err := stream.Send(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send server reflection request: %w", err)
+	}
+
+	response, err := stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("unable to receive server reflection response: %w", err)
+	}
+
+	if errorResponse := response.GetErrorResponse(); errorResponse != nil {
+		return nil, fmt.Errorf("server reflection request failed: %s", errorResponse.GetErrorMessage())
+	}
+
+	return response, nil
+}
+
+func collectFiles(protos map[string]*descriptorpb.FileDescriptorProto, response *reflectionv1.ServerReflectionResponse) error {
+	for _, data := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		file := new(descriptorpb.FileDescriptorProto)
+
+		err := proto.Unmarshal(data, file)
+		if err != nil {
+			return fmt.Errorf("invalid file descriptor: %w", err)
+		}
+
+		protos[file.GetName()] = file
+	}
+
+	return nil
+}
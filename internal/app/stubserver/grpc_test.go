@@ -0,0 +1,435 @@
+package stubserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	getOrderMethod     = "/orders.v1.OrderService/GetOrder"
+	watchOrdersMethod  = "/orders.v1.OrderService/WatchOrders"
+	importOrdersMethod = "/orders.v1.OrderService/ImportOrders"
+)
+
+// ordersDescriptorSet describes an order service with a unary, a server streaming and a client
+// streaming method. The order message refers to a well-known type that the set does not include.
+func ordersDescriptorSet() *descriptorpb.FileDescriptorSet {
+	field := func(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		result := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     fieldType.Enum(),
+			JsonName: proto.String(name),
+		}
+		if typeName != "" {
+			result.TypeName = proto.String(typeName)
+		}
+
+		return result
+	}
+
+	stringType := descriptorpb.FieldDescriptorProto_TYPE_STRING
+
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:       proto.String("orders/v1/orders.proto"),
+		Package:    proto.String("orders.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetOrderRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("id", 1, stringType, "")}},
+			{Name: proto.String("WatchOrdersRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("customer", 1, stringType, "")}},
+			{Name: proto.String("Order"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, stringType, ""),
+				field("status", 2, stringType, ""),
+				field("created", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".google.protobuf.Timestamp"),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("OrderService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("GetOrder"), InputType: proto.String(".orders.v1.GetOrderRequest"), OutputType: proto.String(".orders.v1.Order")},
+				{
+					Name:            proto.String("WatchOrders"),
+					InputType:       proto.String(".orders.v1.WatchOrdersRequest"),
+					OutputType:      proto.String(".orders.v1.Order"),
+					ServerStreaming: proto.Bool(true),
+				},
+				{
+					Name:            proto.String("ImportOrders"),
+					InputType:       proto.String(".orders.v1.Order"),
+					OutputType:      proto.String(".orders.v1.Order"),
+					ClientStreaming: proto.Bool(true),
+				},
+			},
+		}},
+	}}}
+}
+
+// startGRPCServer serves the gRPC server of the stub server and returns a client connection to it.
+func startGRPCServer(t *testing.T, server *Server) *grpc.ClientConn {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := server.GRPCServer()
+
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
+// newMessage returns a message of the named type, filled from its JSON representation.
+func newMessage(t *testing.T, server *Server, name, data string) *dynamicpb.Message {
+	t.Helper()
+
+	descriptor, err := server.descriptors.FindDescriptorByName(protoreflect.FullName(name))
+	require.NoError(t, err)
+
+	message := dynamicpb.NewMessage(descriptor.(protoreflect.MessageDescriptor))
+	require.NoError(t, protojson.Unmarshal([]byte(data), message))
+
+	return message
+}
+
+func TestValidateGRPC(t *testing.T) {
+	grpcID := EndpointID{Path: getOrderMethod, HTTPMethod: http.MethodPost, GRPC: true}
+
+	tests := []struct {
+		name          string
+		config        EndpointConfiguration
+		errorContains string
+	}{
+		{
+			name:   "valid response",
+			config: EndpointConfiguration{EndpointID: grpcID, GRPC: &GRPC{Code: "NOT_FOUND", Messages: []GRPCMessage{{Data: "{}"}}}},
+		},
+		{
+			name:          "missing response",
+			config:        EndpointConfiguration{EndpointID: grpcID},
+			errorContains: "a gRPC endpoint requires a gRPC response",
+		},
+		{
+			name: "GET method",
+			config: EndpointConfiguration{
+				EndpointID: EndpointID{Path: getOrderMethod, HTTPMethod: http.MethodGet, GRPC: true},
+				GRPC:       &GRPC{},
+			},
+			errorContains: "a gRPC endpoint must use the POST method",
+		},
+		{
+			name: "path that is not a method",
+			config: EndpointConfiguration{
+				EndpointID: EndpointID{Path: "/orders", HTTPMethod: http.MethodPost, GRPC: true},
+				GRPC:       &GRPC{},
+			},
+			errorContains: "the path of a gRPC endpoint must be a full method name",
+		},
+		{
+			name: "regex path",
+			config: EndpointConfiguration{
+				EndpointID: EndpointID{Path: "/orders.v1.OrderService/.*", PathMatchType: MatchTypeRegex, HTTPMethod: http.MethodPost, GRPC: true},
+				GRPC:       &GRPC{},
+			},
+		},
+		{
+			name:          "with stream",
+			config:        EndpointConfiguration{EndpointID: grpcID, Stream: &Stream{Mode: StreamModeChunked}, GRPC: &GRPC{}},
+			errorContains: "a gRPC response cannot be combined with a binary response body, stream or WebSocket conversation",
+		},
+		{
+			name:          "binary sequence response",
+			config:        EndpointConfiguration{EndpointID: grpcID, Responses: []Response{{BodyBase64: "AA=="}}, GRPC: &GRPC{}},
+			errorContains: "response 0: a gRPC response body must be JSON",
+		},
+		{
+			name:          "invalid code",
+			config:        EndpointConfiguration{EndpointID: grpcID, GRPC: &GRPC{Code: "LOST"}},
+			errorContains: "invalid gRPC status code: LOST",
+		},
+		{
+			name:          "negative delay",
+			config:        EndpointConfiguration{EndpointID: grpcID, GRPC: &GRPC{Messages: []GRPCMessage{{Delay: -time.Second}}}},
+			errorContains: "gRPC message 0: delay must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGRPC(&tt.config)
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestParseGRPCCode(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected codes.Code
+		valid    bool
+	}{
+		{code: "", expected: codes.OK, valid: true},
+		{code: "NOT_FOUND", expected: codes.NotFound, valid: true},
+		{code: "permission_denied", expected: codes.PermissionDenied, valid: true},
+		{code: "14", expected: codes.Unavailable, valid: true},
+		{code: "17"},
+		{code: "NotFound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			code, err := parseGRPCCode(tt.code)
+			if tt.valid {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, code)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestDescriptorRegistry(t *testing.T) {
+	registry := NewDescriptorRegistry()
+
+	missingDependency := ordersDescriptorSet()
+	missingDependency.File[0].Dependency = append(missingDependency.File[0].Dependency, "customers/v1/customers.proto")
+
+	err := registry.Add(missingDependency)
+	require.ErrorContains(t, err, "invalid descriptor set")
+	assert.Empty(t, registry.Services())
+
+	require.NoError(t, registry.Add(ordersDescriptorSet()))
+	assert.Equal(t, map[string][]string{
+		"orders.v1.OrderService": {getOrderMethod, watchOrdersMethod, importOrdersMethod},
+	}, registry.Services())
+
+	method, err := registry.findMethod(watchOrdersMethod)
+	require.NoError(t, err)
+	assert.True(t, method.IsStreamingServer())
+
+	_, err = registry.findMethod("/orders.v1.OrderService/DeleteOrder")
+	require.ErrorContains(t, err, "unknown method: /orders.v1.OrderService/DeleteOrder")
+
+	_, err = registry.findMethod("/orders.v1.Order/GetOrder")
+	require.ErrorContains(t, err, "unknown service: orders.v1.Order")
+
+	_, err = registry.FindFileByPath("google/protobuf/timestamp.proto")
+	require.NoError(t, err)
+}
+
+func TestLoadDescriptorSet(t *testing.T) {
+	dir := t.TempDir()
+
+	data, err := proto.Marshal(ordersDescriptorSet())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "orders.pb"), data, 0o600))
+
+	server := NewServer()
+
+	require.NoError(t, server.LoadDescriptorSet(filepath.Join(dir, "orders.pb")))
+	assert.Contains(t, server.descriptors.Services(), "orders.v1.OrderService")
+
+	err = server.LoadDescriptorSet(filepath.Join(dir, "missing.pb"))
+	assert.ErrorContains(t, err, "unable to read descriptor set")
+}
+
+//nolint:funlen
+func TestServeGRPCUnary(t *testing.T) {
+	server := NewServer()
+	require.NoError(t, server.descriptors.Add(ordersDescriptorSet()))
+
+	conn := startGRPCServer(t, server)
+	ns := server.namespaces.get(DefaultNamespace)
+
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		ID: "order-42",
+		EndpointID: EndpointID{
+			Path:         getOrderMethod,
+			HTTPMethod:   http.MethodPost,
+			GRPC:         true,
+			BodyMatchers: []BodyMatcher{{MatchType: BodyMatchTypeJSON, Value: `{"id":"42"}`}},
+		},
+		ResponseHeaders: map[string]string{"x-region": "eu"},
+		ResponseBody:    `{"id":"42","status":"shipped","created":"2024-01-02T03:04:05Z"}`,
+		GRPC:            &GRPC{Trailers: map[string]string{"x-trace": "abc"}},
+	}))
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		ID: "order-missing",
+		EndpointID: EndpointID{
+			Path:         getOrderMethod,
+			HTTPMethod:   http.MethodPost,
+			GRPC:         true,
+			BodyMatchers: []BodyMatcher{{MatchType: BodyMatchTypeJSON, Value: `{"id":"7"}`}},
+		},
+		GRPC: &GRPC{Code: "NOT_FOUND", Message: "order 7 not found"},
+	}))
+
+	t.Run("matching request", func(t *testing.T) {
+		var header, trailer metadata.MD
+
+		response := newMessage(t, server, "orders.v1.Order", "{}")
+
+		err := conn.Invoke(t.Context(), getOrderMethod, newMessage(t, server, "orders.v1.GetOrderRequest", `{"id":"42"}`), response,
+			grpc.Header(&header), grpc.Trailer(&trailer))
+		require.NoError(t, err)
+
+		data, err := protojson.Marshal(response)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"id":"42","status":"shipped","created":"2024-01-02T03:04:05Z"}`, string(data))
+		assert.Equal(t, []string{"eu"}, header.Get("x-region"))
+		assert.Equal(t, []string{"abc"}, trailer.Get("x-trace"))
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		err := conn.Invoke(t.Context(), getOrderMethod, newMessage(t, server, "orders.v1.GetOrderRequest", `{"id":"7"}`),
+			newMessage(t, server, "orders.v1.Order", "{}"))
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, "order 7 not found", status.Convert(err).Message())
+	})
+
+	t.Run("unmatched request in namespace", func(t *testing.T) {
+		teamA := server.namespaces.get("team-a")
+		require.NoError(t, teamA.responseManager.AddEndpoint(EndpointConfiguration{
+			EndpointID:   EndpointID{Path: getOrderMethod, HTTPMethod: http.MethodPost},
+			ResponseBody: `{"id":"42"}`,
+		}))
+
+		ctx := metadata.AppendToOutgoingContext(t.Context(), NamespaceHeader, "team-a")
+
+		err := conn.Invoke(ctx, getOrderMethod, newMessage(t, server, "orders.v1.GetOrderRequest", `{"id":"42"}`),
+			newMessage(t, server, "orders.v1.Order", "{}"))
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		unmatched := teamA.journal.Unmatched()
+		require.Len(t, unmatched, 1)
+		assert.Equal(t, getOrderMethod, unmatched[0].Path)
+		assert.JSONEq(t, `{"id":"42"}`, unmatched[0].Body)
+		require.Len(t, unmatched[0].NearMisses, 1)
+		assert.Equal(t, []string{"endpoint does not stub a gRPC method"}, unmatched[0].NearMisses[0].Mismatches)
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		err := conn.Invoke(t.Context(), "/orders.v1.OrderService/DeleteOrder", newMessage(t, server, "orders.v1.GetOrderRequest", "{}"),
+			newMessage(t, server, "orders.v1.Order", "{}"))
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+		assert.Contains(t, status.Convert(err).Message(), "unknown method")
+	})
+
+	t.Run("client streaming method", func(t *testing.T) {
+		stream, err := conn.NewStream(t.Context(), &grpc.StreamDesc{ClientStreams: true}, importOrdersMethod)
+		require.NoError(t, err)
+		require.NoError(t, stream.CloseSend())
+
+		err = stream.RecvMsg(newMessage(t, server, "orders.v1.Order", "{}"))
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+func TestServeGRPCServerStreaming(t *testing.T) {
+	server := NewServer()
+	require.NoError(t, server.descriptors.Add(ordersDescriptorSet()))
+
+	conn := startGRPCServer(t, server)
+
+	require.NoError(t, server.namespaces.get(DefaultNamespace).responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{Path: watchOrdersMethod, HTTPMethod: http.MethodPost, GRPC: true},
+		GRPC: &GRPC{
+			Code:     "UNAVAILABLE",
+			Message:  "feed closed",
+			Messages: []GRPCMessage{{Data: `{"id":"1"}`}, {Delay: 10 * time.Millisecond, Data: `{"id":"2"}`}},
+		},
+	}))
+
+	stream, err := conn.NewStream(t.Context(), &grpc.StreamDesc{ServerStreams: true}, watchOrdersMethod)
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(newMessage(t, server, "orders.v1.WatchOrdersRequest", `{"customer":"acme"}`)))
+	require.NoError(t, stream.CloseSend())
+
+	ids := make([]string, 0)
+
+	for {
+		order := newMessage(t, server, "orders.v1.Order", "{}")
+
+		err = stream.RecvMsg(order)
+		if err != nil {
+			break
+		}
+
+		ids = append(ids, order.Get(order.Descriptor().Fields().ByName("id")).String())
+	}
+
+	assert.Equal(t, []string{"1", "2"}, ids)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, "feed closed", status.Convert(err).Message())
+}
+
+func TestLoadDescriptorsFromReflection(t *testing.T) {
+	upstream := NewServer()
+	require.NoError(t, upstream.descriptors.Add(ordersDescriptorSet()))
+
+	conn := startGRPCServer(t, upstream)
+
+	server := NewServer()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, server.LoadDescriptorsFromReflection(ctx, conn.Target()))
+	assert.Equal(t, upstream.descriptors.Services(), server.descriptors.Services())
+
+	err := server.LoadDescriptorsFromReflection(ctx, "127.0.0.1:1")
+	assert.ErrorContains(t, err, "unable to load descriptors from 127.0.0.1:1")
+}
+
+func TestGRPCNearMiss(t *testing.T) {
+	responseManager := NewResponseManager()
+
+	require.NoError(t, responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{Path: getOrderMethod, HTTPMethod: http.MethodPost, GRPC: true},
+		GRPC:       &GRPC{},
+	}))
+
+	request := EndpointID{Path: getOrderMethod, HTTPMethod: http.MethodPost}
+
+	_, err := responseManager.MatchEndpoint(&request)
+	require.Error(t, err)
+
+	nearMisses := responseManager.NearMisses(&request)
+	require.Len(t, nearMisses, 1)
+	assert.Equal(t, []string{"request is not a gRPC call"}, nearMisses[0].Mismatches)
+}
@@ -0,0 +1,192 @@
+package stubserver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultJournalSize = 1000
+
+// JournalEntry represents a request that was received by the stub server.
+type JournalEntry struct {
+	// ID is a sequence number that reflects the order in which requests were received.
+	ID          int64
+	Timestamp   time.Time
+	Method      string
+	URL         string
+	Path        string
+	QueryParams url.Values
+	Headers     http.Header
+	Body        string
+	// ClientCertSubject is the subject of the client certificate, when one was presented.
+	ClientCertSubject string
+	// MatchedEndpointID is the stub ID of the endpoint that served the request, empty when unmatched.
+	MatchedEndpointID string
+	// NearMisses lists the endpoints that came closest to matching an unmatched request.
+	NearMisses []NearMiss
+	// ContractErrors lists how the request violates the OpenAPI contract of the namespace. A request
+	// rejected by the contract is recorded as unmatched.
+	ContractErrors []string
+}
+
+// JournalFilter represents the criteria to select journal entries. Empty criteria match every entry.
+type JournalFilter struct {
+	// Path is matched like the path of an endpoint, so it may contain templates and wildcards.
+	Path   string
+	Method string
+	// Headers must all be present with the given value. Header names are case-insensitive.
+	Headers map[string]string
+	Since   time.Time
+	Until   time.Time
+}
+
+// RequestJournal keeps the most recent requests received by the stub server in memory.
+type RequestJournal struct {
+	mu      sync.RWMutex
+	entries []JournalEntry
+	maxSize int
+	lastID  int64
+}
+
+// NewRequestJournal creates a new instance of RequestJournal that keeps at most maxSize entries.
+func NewRequestJournal(maxSize int) *RequestJournal {
+	if maxSize <= 0 {
+		maxSize = defaultJournalSize
+	}
+
+	return &RequestJournal{
+		entries: make([]JournalEntry, 0),
+		maxSize: maxSize,
+	}
+}
+
+// Record adds an entry to the journal, dropping the oldest entry when the journal is full.
+func (j *RequestJournal) Record(entry JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.entries) >= j.maxSize {
+		j.entries = j.entries[len(j.entries)-j.maxSize+1:]
+	}
+
+	j.lastID++
+	entry.ID = j.lastID
+
+	j.entries = append(j.entries, entry)
+}
+
+// Find returns the entries matching the filter, oldest first.
+func (j *RequestJournal) Find(filter JournalFilter) []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := make([]JournalEntry, 0)
+
+	for _, entry := range j.entries {
+		if filter.Matches(&entry) {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// Unmatched returns the entries of the requests that matched no endpoint, oldest first.
+func (j *RequestJournal) Unmatched() []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := make([]JournalEntry, 0)
+
+	for _, entry := range j.entries {
+		if entry.MatchedEndpointID == "" {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// FindMatching returns the entries that satisfy every criterion of the endpoint ID, oldest first.
+func (j *RequestJournal) FindMatching(ei *EndpointID) []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := make([]JournalEntry, 0)
+
+	for _, entry := range j.entries {
+		request := entry.endpointID()
+		if ei.MatchesAll(&request) {
+			result = append(result, entry)
+		}
+	}
+
+	return result
+}
+
+// Clear removes all entries from the journal.
+func (j *RequestJournal) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = make([]JournalEntry, 0)
+}
+
+// Matches reports whether the journal entry satisfies the filter.
+func (f *JournalFilter) Matches(entry *JournalEntry) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, entry.Method) {
+		return false
+	}
+
+	if f.Path != "" {
+		if _, ok := matchPath(f.Path, entry.Path); !ok {
+			return false
+		}
+	}
+
+	for name, value := range f.Headers {
+		if entry.Headers.Get(name) != value {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && entry.Timestamp.After(f.Until) {
+		return false
+	}
+
+	return true
+}
+
+// endpointID converts the entry to the representation of an incoming request used for matching.
+func (e *JournalEntry) endpointID() EndpointID {
+	return EndpointID{
+		Path:               e.Path,
+		HTTPMethod:         e.Method,
+		QueryParamsToMatch: firstValues(e.QueryParams),
+		HeadersToMatch:     firstValues(e.Headers),
+		QueryParams:        e.QueryParams,
+		Headers:            e.Headers,
+		ClientCertSubject:  e.ClientCertSubject,
+		WebSocket:          isWebSocketUpgrade(e.Headers),
+		Body:               []byte(e.Body),
+	}
+}
+
+func firstValues[T ~map[string][]string](values T) map[string]string {
+	result := make(map[string]string, len(values))
+
+	for key, value := range values {
+		if len(value) > 0 {
+			result[key] = value[0]
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,161 @@
+package stubserver
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestJournalRecordIsBounded(t *testing.T) {
+	journal := NewRequestJournal(3)
+
+	for i := range 5 {
+		journal.Record(JournalEntry{Method: "GET", Path: fmt.Sprintf("/api/v1/items/%d", i)})
+	}
+
+	entries := journal.Find(JournalFilter{})
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "/api/v1/items/2", entries[0].Path)
+	assert.Equal(t, "/api/v1/items/4", entries[2].Path)
+
+	journal.Clear()
+	assert.Empty(t, journal.Find(JournalFilter{}))
+}
+
+//nolint:funlen
+func TestRequestJournalFind(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	journal := NewRequestJournal(10)
+	journal.Record(JournalEntry{
+		Timestamp: start,
+		Method:    "GET",
+		Path:      "/api/v1/users/1",
+		Headers:   http.Header{"X-Tenant": []string{"a"}},
+	})
+	journal.Record(JournalEntry{
+		Timestamp: start.Add(time.Minute),
+		Method:    "POST",
+		Path:      "/api/v1/users",
+		Headers:   http.Header{"X-Tenant": []string{"b"}},
+	})
+	journal.Record(JournalEntry{
+		Timestamp: start.Add(2 * time.Minute),
+		Method:    "GET",
+		Path:      "/api/v1/users/2",
+		Headers:   http.Header{"X-Tenant": []string{"b"}},
+	})
+
+	tests := []struct {
+		name          string
+		filter        JournalFilter
+		expectedPaths []string
+	}{
+		{
+			name:          "no filter",
+			filter:        JournalFilter{},
+			expectedPaths: []string{"/api/v1/users/1", "/api/v1/users", "/api/v1/users/2"},
+		},
+		{
+			name:          "method",
+			filter:        JournalFilter{Method: "post"},
+			expectedPaths: []string{"/api/v1/users"},
+		},
+		{
+			name:          "path template",
+			filter:        JournalFilter{Path: "/api/v1/users/{id}"},
+			expectedPaths: []string{"/api/v1/users/1", "/api/v1/users/2"},
+		},
+		{
+			name:          "header is case-insensitive",
+			filter:        JournalFilter{Headers: map[string]string{"x-tenant": "b"}},
+			expectedPaths: []string{"/api/v1/users", "/api/v1/users/2"},
+		},
+		{
+			name:          "time range",
+			filter:        JournalFilter{Since: start.Add(30 * time.Second), Until: start.Add(90 * time.Second)},
+			expectedPaths: []string{"/api/v1/users"},
+		},
+		{
+			name:          "no matches",
+			filter:        JournalFilter{Method: "DELETE"},
+			expectedPaths: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := []string{}
+			for _, entry := range journal.Find(tt.filter) {
+				paths = append(paths, entry.Path)
+			}
+
+			assert.Equal(t, tt.expectedPaths, paths)
+		})
+	}
+}
+
+func TestRequestJournalFindMatching(t *testing.T) {
+	journal := NewRequestJournal(10)
+	journal.Record(JournalEntry{
+		Method:      "POST",
+		Path:        "/api/v1/notifications",
+		QueryParams: map[string][]string{"channel": {"email"}},
+		Body:        `{"to":"a@example.com"}`,
+	})
+	journal.Record(JournalEntry{
+		Method:      "POST",
+		Path:        "/api/v1/notifications",
+		QueryParams: map[string][]string{"channel": {"sms"}},
+		Body:        `{"to":"+31600000000"}`,
+	})
+	journal.Record(JournalEntry{Method: "GET", Path: "/api/v1/notifications/1"})
+
+	tests := []struct {
+		name        string
+		matcher     EndpointID
+		expectedIDs []int64
+	}{
+		{
+			name:        "empty matcher",
+			matcher:     EndpointID{},
+			expectedIDs: []int64{1, 2, 3},
+		},
+		{
+			name:        "method and path template",
+			matcher:     EndpointID{HTTPMethod: "GET", Path: "/api/v1/notifications/{id}"},
+			expectedIDs: []int64{3},
+		},
+		{
+			name:        "query parameter",
+			matcher:     EndpointID{Path: "/api/v1/notifications", QueryParamsToMatch: map[string]string{"channel": "sms"}},
+			expectedIDs: []int64{2},
+		},
+		{
+			name: "body matcher",
+			matcher: EndpointID{BodyMatchers: []BodyMatcher{
+				{MatchType: BodyMatchTypeJSONSubset, Value: `{"to":"a@example.com"}`},
+			}},
+			expectedIDs: []int64{1},
+		},
+		{
+			name:        "every criterion has to match",
+			matcher:     EndpointID{HTTPMethod: "GET", QueryParamsToMatch: map[string]string{"channel": "sms"}},
+			expectedIDs: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := []int64{}
+			for _, entry := range journal.FindMatching(&tt.matcher) {
+				ids = append(ids, entry.ID)
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
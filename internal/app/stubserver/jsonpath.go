@@ -0,0 +1,328 @@
+package stubserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep represents a single step of a compiled JSONPath expression.
+type jsonPathStep struct {
+	name      string
+	index     *int
+	wildcard  bool
+	recursive bool
+	filter    *jsonPathFilter
+}
+
+// jsonPathFilter represents a filter expression like [?(@.type == 'book')].
+type jsonPathFilter struct {
+	path     []jsonPathStep
+	operator string
+	value    any
+}
+
+// evaluateJSONPath evaluates a JSONPath expression against a decoded JSON document. The supported
+// subset covers child access ($.a.b and $['a']), array indices ($.a[0], $.a[-1]), wildcards
+// ($.a[*], $.a.*), recursive descent ($..a) and simple filters ($.a[?(@.b == 'c')]).
+func evaluateJSONPath(expression string, document any) ([]any, error) {
+	steps, err := compileJSONPath(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return applyJSONPath(steps, []any{document}), nil
+}
+
+func compileJSONPath(expression string) ([]jsonPathStep, error) {
+	expression = strings.TrimSpace(expression)
+	if !strings.HasPrefix(expression, "$") {
+		return nil, fmt.Errorf("invalid JSONPath expression %q: must start with $", expression)
+	}
+
+	return parseJSONPathSteps(expression, expression[1:])
+}
+
+//nolint:cyclop,funlen
+func parseJSONPathSteps(expression, rest string) ([]jsonPathStep, error) {
+	steps := []jsonPathStep{}
+
+	for len(rest) > 0 {
+		recursive := false
+
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			recursive = true
+			rest = rest[2:]
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] != '[':
+			return nil, fmt.Errorf("invalid JSONPath expression %q: unexpected %q", expression, rest[0])
+		}
+
+		if len(rest) > 0 && rest[0] == '[' {
+			end := matchingBracket(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath expression %q: unclosed bracket", expression)
+			}
+
+			step, err := parseJSONPathBracket(expression, rest[1:end])
+			if err != nil {
+				return nil, err
+			}
+
+			step.recursive = recursive
+			steps = append(steps, step)
+			rest = rest[end+1:]
+
+			continue
+		}
+
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+
+		name := rest[:end]
+		if name == "" {
+			return nil, fmt.Errorf("invalid JSONPath expression %q: empty member name", expression)
+		}
+
+		steps = append(steps, jsonPathStep{name: name, wildcard: name == "*", recursive: recursive})
+		rest = rest[end:]
+	}
+
+	return steps, nil
+}
+
+func parseJSONPathBracket(expression, content string) (jsonPathStep, error) {
+	content = strings.TrimSpace(content)
+
+	switch {
+	case content == "*":
+		return jsonPathStep{wildcard: true}, nil
+	case strings.HasPrefix(content, "?(") && strings.HasSuffix(content, ")"):
+		filter, err := parseJSONPathFilter(expression, content[2:len(content)-1])
+		if err != nil {
+			return jsonPathStep{}, err
+		}
+
+		return jsonPathStep{filter: filter}, nil
+	case isQuoted(content):
+		return jsonPathStep{name: content[1 : len(content)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return jsonPathStep{}, fmt.Errorf("invalid JSONPath expression %q: invalid index %q", expression, content)
+	}
+
+	return jsonPathStep{index: &index}, nil
+}
+
+func parseJSONPathFilter(expression, content string) (*jsonPathFilter, error) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "@") {
+		return nil, fmt.Errorf("invalid JSONPath expression %q: filter must start with @", expression)
+	}
+
+	for _, operator := range []string{"==", "!="} {
+		left, right, found := strings.Cut(content, operator)
+		if !found {
+			continue
+		}
+
+		path, err := parseJSONPathSteps(expression, strings.TrimSpace(left)[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := parseJSONPathLiteral(strings.TrimSpace(right))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath expression %q: %w", expression, err)
+		}
+
+		return &jsonPathFilter{path: path, operator: operator, value: value}, nil
+	}
+
+	path, err := parseJSONPathSteps(expression, content[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonPathFilter{path: path}, nil
+}
+
+func parseJSONPathLiteral(literal string) (any, error) {
+	if isQuoted(literal) {
+		return literal[1 : len(literal)-1], nil
+	}
+
+	var value any
+
+	err := json.Unmarshal([]byte(literal), &value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid literal %q", literal)
+	}
+
+	return value, nil
+}
+
+func applyJSONPath(steps []jsonPathStep, nodes []any) []any {
+	for _, step := range steps {
+		next := []any{}
+
+		for _, node := range nodes {
+			if step.recursive {
+				for _, descendant := range descendants(node) {
+					next = append(next, applyJSONPathStep(step, descendant)...)
+				}
+
+				continue
+			}
+
+			next = append(next, applyJSONPathStep(step, node)...)
+		}
+
+		nodes = next
+	}
+
+	return nodes
+}
+
+//nolint:cyclop
+func applyJSONPathStep(step jsonPathStep, node any) []any {
+	switch {
+	case step.wildcard:
+		return children(node)
+	case step.filter != nil:
+		result := []any{}
+
+		for _, child := range children(node) {
+			if step.filter.matches(child) {
+				result = append(result, child)
+			}
+		}
+
+		return result
+	case step.index != nil:
+		array, ok := node.([]any)
+		if !ok {
+			return nil
+		}
+
+		index := *step.index
+		if index < 0 {
+			index += len(array)
+		}
+
+		if index < 0 || index >= len(array) {
+			return nil
+		}
+
+		return []any{array[index]}
+	}
+
+	object, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	value, exists := object[step.name]
+	if !exists {
+		return nil
+	}
+
+	return []any{value}
+}
+
+func (f *jsonPathFilter) matches(node any) bool {
+	results := applyJSONPath(f.path, []any{node})
+
+	switch f.operator {
+	case "==":
+		return len(results) > 0 && reflect.DeepEqual(results[0], f.value)
+	case "!=":
+		return len(results) > 0 && !reflect.DeepEqual(results[0], f.value)
+	}
+
+	return len(results) > 0
+}
+
+func children(node any) []any {
+	switch typed := node.(type) {
+	case []any:
+		return typed
+	case map[string]any:
+		result := make([]any, 0, len(typed))
+		for _, key := range sortedKeys(typed) {
+			result = append(result, typed[key])
+		}
+
+		return result
+	}
+
+	return nil
+}
+
+func descendants(node any) []any {
+	result := []any{node}
+
+	for _, child := range children(node) {
+		result = append(result, descendants(child)...)
+	}
+
+	return result
+}
+
+func matchingBracket(s string) int {
+	inQuote := byte(0)
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case inQuote != 0:
+			if s[i] == inQuote {
+				inQuote = 0
+			}
+		case s[i] == '\'' || s[i] == '"':
+			inQuote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// jsonValueToString converts a value selected by a JSONPath expression to a string, so that it
+// can be compared using a ValueMatcher. Strings are returned as is, other values as JSON.
+func jsonValueToString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(b)
+}
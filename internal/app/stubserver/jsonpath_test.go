@@ -0,0 +1,105 @@
+package stubserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jsonPathTestDocument = `{
+	"order": {
+		"id": "order-1",
+		"customer": {"name": "John"},
+		"items": [
+			{"sku": "a", "quantity": 1, "type": "book"},
+			{"sku": "b", "quantity": 2, "type": "music"}
+		]
+	}
+}`
+
+//nolint:funlen
+func TestEvaluateJSONPath(t *testing.T) {
+	var document any
+
+	require.NoError(t, json.Unmarshal([]byte(jsonPathTestDocument), &document))
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   []any
+	}{
+		{
+			name:       "root",
+			expression: "$",
+			expected:   []any{document},
+		},
+		{
+			name:       "child",
+			expression: "$.order.id",
+			expected:   []any{"order-1"},
+		},
+		{
+			name:       "bracket child",
+			expression: "$['order']['customer']['name']",
+			expected:   []any{"John"},
+		},
+		{
+			name:       "array index",
+			expression: "$.order.items[1].sku",
+			expected:   []any{"b"},
+		},
+		{
+			name:       "negative array index",
+			expression: "$.order.items[-1].sku",
+			expected:   []any{"b"},
+		},
+		{
+			name:       "wildcard",
+			expression: "$.order.items[*].quantity",
+			expected:   []any{float64(1), float64(2)},
+		},
+		{
+			name:       "recursive descent",
+			expression: "$..sku",
+			expected:   []any{"a", "b"},
+		},
+		{
+			name:       "filter",
+			expression: "$.order.items[?(@.type == 'music')].sku",
+			expected:   []any{"b"},
+		},
+		{
+			name:       "filter with number",
+			expression: "$.order.items[?(@.quantity != 2)].sku",
+			expected:   []any{"a"},
+		},
+		{
+			name:       "missing member",
+			expression: "$.order.unknown",
+			expected:   []any{},
+		},
+		{
+			name:       "index out of range",
+			expression: "$.order.items[5]",
+			expected:   []any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evaluateJSONPath(tt.expression, document)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestEvaluateJSONPathInvalidExpression(t *testing.T) {
+	for _, expression := range []string{"order.id", "$.order[", "$.order[abc]", "$.items[?(type == 'a')]"} {
+		_, err := evaluateJSONPath(expression, map[string]any{})
+		assert.Error(t, err, expression)
+		assert.Contains(t, err.Error(), "invalid JSONPath expression", expression)
+	}
+}
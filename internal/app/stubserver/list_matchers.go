@@ -0,0 +1,164 @@
+package stubserver
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ListMatchType represents the way the values of a repeated query parameter or header are compared
+// with the expected values.
+type ListMatchType string
+
+const (
+	// ListMatchTypeContainsAll matches when every expected value is present, among other values.
+	ListMatchTypeContainsAll ListMatchType = "containsAll"
+	// ListMatchTypeExactSet matches when the values are exactly the expected values, in any order.
+	ListMatchTypeExactSet ListMatchType = "exactSet"
+	// ListMatchTypeAnyOf matches when at least one of the values is one of the expected values.
+	ListMatchTypeAnyOf ListMatchType = "anyOf"
+)
+
+// ListMatcher describes how the values of a repeated query parameter or header should be matched.
+type ListMatcher struct {
+	MatchType ListMatchType
+	Values    []string
+}
+
+// String returns a textual representation of the matcher, which is used to build endpoint IDs.
+func (lm ListMatcher) String() string {
+	values := slices.Clone(lm.Values)
+	sort.Strings(values)
+
+	return fmt.Sprintf("%s[%s]", lm.MatchType, strings.Join(values, ","))
+}
+
+// Validate checks whether the matcher type is supported and expected values are given.
+func (lm ListMatcher) Validate() error {
+	switch lm.MatchType {
+	case ListMatchTypeContainsAll, ListMatchTypeExactSet, ListMatchTypeAnyOf:
+	default:
+		return fmt.Errorf("invalid list match type: %s", lm.MatchType)
+	}
+
+	if len(lm.Values) == 0 {
+		return fmt.Errorf("list matcher %s requires values", lm.MatchType)
+	}
+
+	return nil
+}
+
+// Matches reports whether the values of the request satisfy the matcher.
+func (lm ListMatcher) Matches(values []string) bool {
+	switch lm.MatchType {
+	case ListMatchTypeContainsAll:
+		return containsEvery(values, lm.Values)
+	case ListMatchTypeExactSet:
+		return containsEvery(values, lm.Values) && containsEvery(lm.Values, values)
+	case ListMatchTypeAnyOf:
+		return slices.ContainsFunc(values, func(value string) bool {
+			return slices.Contains(lm.Values, value)
+		})
+	}
+
+	return false
+}
+
+func containsEvery(values, expected []string) bool {
+	for _, value := range expected {
+		if !slices.Contains(values, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateListMatchers(ei *EndpointID) error {
+	for name, matcher := range ei.QueryParamListMatchers {
+		err := matcher.Validate()
+		if err != nil {
+			return fmt.Errorf("query parameter %s: %w", name, err)
+		}
+	}
+
+	for name, matcher := range ei.HeaderListMatchers {
+		err := matcher.Validate()
+		if err != nil {
+			return fmt.Errorf("header %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// countMatchingLists counts the list matchers that are satisfied by the values of the request.
+// Header names are compared case-insensitively.
+func countMatchingLists(matchers map[string]ListMatcher, values map[string][]string, headers bool) int {
+	counter := 0
+
+	for name, matcher := range matchers {
+		actual, present := lookupValue(values, name, headers)
+		if present && matcher.Matches(actual) {
+			counter++
+		}
+	}
+
+	return counter
+}
+
+// matchesAllLists reports whether the values of the request satisfy every list matcher. Header
+// names are compared case-insensitively.
+func matchesAllLists(matchers map[string]ListMatcher, values map[string][]string, headers bool) bool {
+	return countMatchingLists(matchers, values, headers) == len(matchers)
+}
+
+// requestValues returns every value of the query parameters or headers of a request. Requests that
+// were built with single values only fall back to those.
+func requestValues(values map[string][]string, singleValues map[string]string) map[string][]string {
+	if values != nil {
+		return values
+	}
+
+	result := make(map[string][]string, len(singleValues))
+	for name, value := range singleValues {
+		result[name] = []string{value}
+	}
+
+	return result
+}
+
+// lookupValue returns the value of a query parameter or, when headers is set, of a header.
+func lookupValue[T any](values map[string]T, name string, headers bool) (T, bool) {
+	if headers {
+		return lookupHeader(values, name)
+	}
+
+	value, present := values[name]
+
+	return value, present
+}
+
+// lookupHeader returns the value of a header, comparing header names case-insensitively as HTTP
+// requires.
+func lookupHeader[T any](headers map[string]T, name string) (T, bool) {
+	if value, present := headers[name]; present {
+		return value, true
+	}
+
+	if value, present := headers[http.CanonicalHeaderKey(name)]; present {
+		return value, true
+	}
+
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	var zero T
+
+	return zero, false
+}
@@ -0,0 +1,211 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestListMatcherMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		matcher  ListMatcher
+		values   []string
+		expected bool
+	}{
+		{
+			name:     "contains all match",
+			matcher:  ListMatcher{MatchType: ListMatchTypeContainsAll, Values: []string{"a", "b"}},
+			values:   []string{"c", "b", "a"},
+			expected: true,
+		},
+		{
+			name:     "contains all mismatch",
+			matcher:  ListMatcher{MatchType: ListMatchTypeContainsAll, Values: []string{"a", "b"}},
+			values:   []string{"a", "c"},
+			expected: false,
+		},
+		{
+			name:     "exact set match in any order",
+			matcher:  ListMatcher{MatchType: ListMatchTypeExactSet, Values: []string{"a", "b"}},
+			values:   []string{"b", "a"},
+			expected: true,
+		},
+		{
+			name:     "exact set mismatch on extra value",
+			matcher:  ListMatcher{MatchType: ListMatchTypeExactSet, Values: []string{"a", "b"}},
+			values:   []string{"a", "b", "c"},
+			expected: false,
+		},
+		{
+			name:     "any of match",
+			matcher:  ListMatcher{MatchType: ListMatchTypeAnyOf, Values: []string{"a", "b"}},
+			values:   []string{"c", "b"},
+			expected: true,
+		},
+		{
+			name:     "any of mismatch",
+			matcher:  ListMatcher{MatchType: ListMatchTypeAnyOf, Values: []string{"a", "b"}},
+			values:   []string{"c"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.matcher.Matches(tt.values))
+		})
+	}
+}
+
+func TestListMatcherValidate(t *testing.T) {
+	assert.NoError(t, ListMatcher{MatchType: ListMatchTypeAnyOf, Values: []string{"a"}}.Validate())
+
+	err := ListMatcher{MatchType: "someOf", Values: []string{"a"}}.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid list match type: someOf")
+
+	err = ListMatcher{MatchType: ListMatchTypeExactSet}.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires values")
+}
+
+//nolint:funlen
+func TestMatchEndpointWithListMatchers(t *testing.T) {
+	rm := NewResponseManager()
+
+	endpoints := []EndpointConfiguration{
+		{
+			EndpointID: EndpointID{
+				Path:       "/api/v1/articles",
+				HTTPMethod: "GET",
+				QueryParamListMatchers: map[string]ListMatcher{
+					"tag": {MatchType: ListMatchTypeExactSet, Values: []string{"go", "http"}},
+				},
+			},
+			ResponseBody: "tagged",
+		},
+		{
+			EndpointID: EndpointID{
+				Path:       "/api/v1/articles",
+				HTTPMethod: "POST",
+				HeaderListMatchers: map[string]ListMatcher{
+					"accept": {MatchType: ListMatchTypeAnyOf, Values: []string{"application/xml"}},
+				},
+			},
+			ResponseBody: "xml",
+		},
+		{
+			EndpointID: EndpointID{
+				Path:       "/api/v1/articles",
+				HTTPMethod: "GET",
+			},
+			ResponseBody: "all",
+		},
+	}
+
+	for _, endpoint := range endpoints {
+		require.NoError(t, rm.AddEndpoint(endpoint))
+	}
+
+	tests := []struct {
+		name     string
+		request  EndpointID
+		expected string
+	}{
+		{
+			name: "every value of a repeated query parameter is matched",
+			request: EndpointID{
+				Path:        "/api/v1/articles",
+				HTTPMethod:  "GET",
+				QueryParams: map[string][]string{"tag": {"http", "go"}},
+			},
+			expected: "tagged",
+		},
+		{
+			name: "a subset of the values does not match the exact set",
+			request: EndpointID{
+				Path:        "/api/v1/articles",
+				HTTPMethod:  "GET",
+				QueryParams: map[string][]string{"tag": {"go"}},
+			},
+			expected: "all",
+		},
+		{
+			name: "header names are case-insensitive",
+			request: EndpointID{
+				Path:       "/api/v1/articles",
+				HTTPMethod: "POST",
+				Headers:    map[string][]string{"Accept": {"application/json", "application/xml"}},
+			},
+			expected: "xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rm.MatchEndpoint(&tt.request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.ResponseBody)
+		})
+	}
+}
+
+func TestMatchEndpointRejectsFailingListMatchers(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{
+			Path:       "/api/v1/articles",
+			HTTPMethod: "GET",
+			QueryParamListMatchers: map[string]ListMatcher{
+				"tag": {MatchType: ListMatchTypeExactSet, Values: []string{"go", "http"}},
+			},
+			HeaderListMatchers: map[string]ListMatcher{
+				"accept": {MatchType: ListMatchTypeContainsAll, Values: []string{"application/json"}},
+			},
+		},
+	}))
+
+	headers := map[string][]string{"Accept": {"application/json"}}
+
+	_, err := rm.MatchEndpoint(&EndpointID{
+		Path:        "/api/v1/articles",
+		HTTPMethod:  "GET",
+		QueryParams: map[string][]string{"tag": {"go", "grpc"}},
+		Headers:     headers,
+	})
+	require.Error(t, err, "repeated values that do not match reject the endpoint")
+
+	_, err = rm.MatchEndpoint(&EndpointID{
+		Path:        "/api/v1/articles",
+		HTTPMethod:  "GET",
+		QueryParams: map[string][]string{"tag": {"go", "http"}},
+	})
+	require.Error(t, err, "a missing header rejects the endpoint")
+
+	_, err = rm.MatchEndpoint(&EndpointID{
+		Path:        "/api/v1/articles",
+		HTTPMethod:  "GET",
+		QueryParams: map[string][]string{"tag": {"http", "go"}},
+		Headers:     headers,
+	})
+	assert.NoError(t, err)
+}
+
+func TestMatchesAllHeaderNamesAreCaseInsensitive(t *testing.T) {
+	matcher := EndpointID{
+		HeadersToMatch: map[string]string{"x-request-id": "42"},
+		HeaderMatchers: map[string]ValueMatcher{"AUTHORIZATION": {MatchType: MatchTypePrefix, Value: "Bearer "}},
+	}
+
+	request := EndpointID{
+		Path:           "/",
+		HTTPMethod:     "GET",
+		HeadersToMatch: map[string]string{"X-Request-Id": "42", "Authorization": "Bearer abc"},
+	}
+
+	assert.True(t, matcher.MatchesAll(&request))
+}
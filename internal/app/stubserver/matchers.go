@@ -0,0 +1,272 @@
+package stubserver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MatchType represents the way a value of a request is compared with the expected value.
+type MatchType string
+
+const (
+	// MatchTypeExact matches when the value is equal to the expected value.
+	MatchTypeExact MatchType = "exact"
+	// MatchTypeRegex matches when the expected regular expression matches the value or a part of
+	// it. Use ^ and $ to match the whole value.
+	MatchTypeRegex MatchType = "regex"
+	// MatchTypePrefix matches when the value starts with the expected value.
+	MatchTypePrefix MatchType = "prefix"
+	// MatchTypeContains matches when the value contains the expected value.
+	MatchTypeContains MatchType = "contains"
+	// MatchTypePresent matches when the value is present, whatever it is.
+	MatchTypePresent MatchType = "present"
+)
+
+// ValueMatcher describes how a path, query parameter or header value should be matched.
+type ValueMatcher struct {
+	MatchType MatchType
+	Value     string
+
+	// regex is the compiled regular expression of a regex matcher once it is compiled.
+	regex *regexp.Regexp
+}
+
+// String returns a textual representation of the matcher, which is used to build endpoint IDs.
+func (vm ValueMatcher) String() string {
+	return fmt.Sprintf("%s(%s)", vm.MatchType, vm.Value)
+}
+
+// Validate checks whether the matcher type is supported and, for regular expressions, whether it compiles.
+func (vm ValueMatcher) Validate() error {
+	switch vm.MatchType {
+	case MatchTypeExact, MatchTypePrefix, MatchTypeContains, MatchTypePresent:
+		return nil
+	case MatchTypeRegex:
+		_, err := regexp.Compile(vm.Value)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", vm.Value, err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("invalid match type: %s", vm.MatchType)
+}
+
+// Matches reports whether the given value satisfies the matcher. The present flag indicates
+// whether the value was part of the request at all.
+func (vm ValueMatcher) Matches(value string, present bool) bool {
+	if !present {
+		return false
+	}
+
+	switch vm.MatchType {
+	case MatchTypeExact:
+		return value == vm.Value
+	case MatchTypeRegex:
+		if vm.regex != nil {
+			return vm.regex.MatchString(value)
+		}
+
+		matched, err := regexp.MatchString(vm.Value, value)
+
+		return err == nil && matched
+	case MatchTypePrefix:
+		return strings.HasPrefix(value, vm.Value)
+	case MatchTypeContains:
+		return strings.Contains(value, vm.Value)
+	case MatchTypePresent:
+		return true
+	}
+
+	return false
+}
+
+// compile returns the matcher with its regular expression compiled, so that matching values does
+// not compile it again. Matchers that do not compile are returned as is.
+func (vm ValueMatcher) compile() ValueMatcher {
+	if vm.MatchType == MatchTypeRegex && vm.regex == nil {
+		vm.regex, _ = regexp.Compile(vm.Value)
+	}
+
+	return vm
+}
+
+func compileValueMatchers(matchers map[string]ValueMatcher) map[string]ValueMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	compiled := make(map[string]ValueMatcher, len(matchers))
+	for name, matcher := range matchers {
+		compiled[name] = matcher.compile()
+	}
+
+	return compiled
+}
+
+// compileMatchers compiles the regular expressions of the path, value and body matchers of the
+// endpoint ID once, instead of for every request they are matched against.
+func compileMatchers(ei *EndpointID) {
+	if ei.PathMatchType == MatchTypeRegex {
+		ei.pathRegex, _ = regexp.Compile(ei.Path)
+	}
+
+	ei.QueryParamMatchers = compileValueMatchers(ei.QueryParamMatchers)
+	ei.HeaderMatchers = compileValueMatchers(ei.HeaderMatchers)
+
+	if ei.ClientCertSubjectMatcher != nil {
+		matcher := ei.ClientCertSubjectMatcher.compile()
+		ei.ClientCertSubjectMatcher = &matcher
+	}
+
+	ei.BodyMatchers = compileBodyMatchers(ei.BodyMatchers)
+}
+
+func validateMatchers(ei *EndpointID) error {
+	switch ei.PathMatchType {
+	case "":
+		err := validatePathTemplate(ei.Path)
+		if err != nil {
+			return err
+		}
+	case MatchTypeExact:
+	case MatchTypePresent:
+		return fmt.Errorf("invalid path match type: %s", ei.PathMatchType)
+	default:
+		err := ValueMatcher{MatchType: ei.PathMatchType, Value: ei.Path}.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, matcher := range ei.QueryParamMatchers {
+		err := matcher.Validate()
+		if err != nil {
+			return fmt.Errorf("query parameter %s: %w", name, err)
+		}
+	}
+
+	for name, matcher := range ei.HeaderMatchers {
+		err := matcher.Validate()
+		if err != nil {
+			return fmt.Errorf("header %s: %w", name, err)
+		}
+	}
+
+	if ei.ClientCertSubjectMatcher != nil {
+		err := ei.ClientCertSubjectMatcher.Validate()
+		if err != nil {
+			return fmt.Errorf("client certificate subject: %w", err)
+		}
+	}
+
+	return validateListMatchers(ei)
+}
+
+// matchEndpointPath matches the request path against the path of the stub, taking the path match
+// type into account. Path variables are only captured for templates; an exact path is compared as
+// a literal string.
+func matchEndpointPath(stub *EndpointID, path string) (map[string]string, bool) {
+	if stub.PathMatchType == "" {
+		return matchPath(stub.Path, path)
+	}
+
+	matcher := ValueMatcher{MatchType: stub.PathMatchType, Value: stub.Path, regex: stub.pathRegex}
+	if !matcher.Matches(path, true) {
+		return nil, false
+	}
+
+	return map[string]string{}, true
+}
+
+// endpointPathSpecificity returns how specific the stub path is. An exact path counts as literal
+// segments only, and paths that are matched by pattern are considered less specific than any
+// template.
+func endpointPathSpecificity(stub *EndpointID) int {
+	switch stub.PathMatchType {
+	case "":
+		return pathSpecificity(stub.Path)
+	case MatchTypeExact:
+		return len(splitPath(stub.Path)) * literalSegmentWeight
+	}
+
+	return 0
+}
+
+// countMatchingValues counts the matchers that are satisfied by the values of the request. When
+// headers is set, names are compared case-insensitively.
+func countMatchingValues(matchers map[string]ValueMatcher, values map[string]string, headers bool) int {
+	counter := 0
+
+	for name, matcher := range matchers {
+		value, present := lookupValue(values, name, headers)
+		if matcher.Matches(value, present) {
+			counter++
+		}
+	}
+
+	return counter
+}
+
+// matchesAllValues reports whether the values of the request satisfy every matcher. When headers
+// is set, names are compared case-insensitively.
+func matchesAllValues(matchers map[string]ValueMatcher, values map[string]string, headers bool) bool {
+	return countMatchingValues(matchers, values, headers) == len(matchers)
+}
+
+// MatchesAll reports whether the request satisfies every criterion of the endpoint ID. Unlike
+// MatchEndpoint, which selects the best scoring endpoint, a single failing criterion rejects the
+// request. An empty path or method, or the method ANY, matches any request.
+//
+//nolint:cyclop
+func (ei *EndpointID) MatchesAll(request *EndpointID) bool {
+	if ei.HTTPMethod != "" && ei.HTTPMethod != AnyHTTPMethod && ei.HTTPMethod != request.HTTPMethod {
+		return false
+	}
+
+	if ei.Path != "" {
+		if _, ok := matchEndpointPath(ei, request.Path); !ok {
+			return false
+		}
+	}
+
+	if !containsAllValues(ei.QueryParamsToMatch, request.QueryParamsToMatch, false) ||
+		!containsAllValues(ei.HeadersToMatch, request.HeadersToMatch, true) {
+		return false
+	}
+
+	if !matchesAllValues(ei.QueryParamMatchers, request.QueryParamsToMatch, false) ||
+		!matchesAllValues(ei.HeaderMatchers, request.HeadersToMatch, true) {
+		return false
+	}
+
+	queryParams := requestValues(request.QueryParams, request.QueryParamsToMatch)
+	headers := requestValues(request.Headers, request.HeadersToMatch)
+
+	if !matchesAllLists(ei.QueryParamListMatchers, queryParams, false) ||
+		!matchesAllLists(ei.HeaderListMatchers, headers, true) {
+		return false
+	}
+
+	if ei.ClientCertSubjectMatcher != nil && !ei.ClientCertSubjectMatcher.Matches(request.ClientCertSubject, request.ClientCertSubject != "") {
+		return false
+	}
+
+	if ei.WebSocket && !request.WebSocket {
+		return false
+	}
+
+	return matchesAllBodyMatchers(ei.BodyMatchers, request.Body)
+}
+
+func containsAllValues(expected, actual map[string]string, headers bool) bool {
+	for name, expectedValue := range expected {
+		if value, present := lookupValue(actual, name, headers); !present || value != expectedValue {
+			return false
+		}
+	}
+
+	return true
+}
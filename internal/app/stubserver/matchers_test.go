@@ -0,0 +1,268 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestValueMatcherMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		matcher  ValueMatcher
+		value    string
+		present  bool
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			matcher:  ValueMatcher{MatchType: MatchTypeExact, Value: "foo"},
+			value:    "foo",
+			present:  true,
+			expected: true,
+		},
+		{
+			name:     "exact mismatch",
+			matcher:  ValueMatcher{MatchType: MatchTypeExact, Value: "foo"},
+			value:    "foobar",
+			present:  true,
+			expected: false,
+		},
+		{
+			name:     "regex match",
+			matcher:  ValueMatcher{MatchType: MatchTypeRegex, Value: `^Bearer .+$`},
+			value:    "Bearer abc.def.ghi",
+			present:  true,
+			expected: true,
+		},
+		{
+			name:     "regex mismatch",
+			matcher:  ValueMatcher{MatchType: MatchTypeRegex, Value: `^\d+$`},
+			value:    "abc",
+			present:  true,
+			expected: false,
+		},
+		{
+			name:     "regex searches a part of the value",
+			matcher:  ValueMatcher{MatchType: MatchTypeRegex, Value: "/orders"},
+			value:    "/api/orders/x",
+			present:  true,
+			expected: true,
+		},
+		{
+			name:     "compiled regex match",
+			matcher:  ValueMatcher{MatchType: MatchTypeRegex, Value: `^\d+$`}.compile(),
+			value:    "42",
+			present:  true,
+			expected: true,
+		},
+		{
+			name:     "prefix match",
+			matcher:  ValueMatcher{MatchType: MatchTypePrefix, Value: "application/"},
+			value:    "application/json",
+			present:  true,
+			expected: true,
+		},
+		{
+			name:     "contains match",
+			matcher:  ValueMatcher{MatchType: MatchTypeContains, Value: "json"},
+			value:    "application/json; charset=utf-8",
+			present:  true,
+			expected: true,
+		},
+		{
+			name:     "present match",
+			matcher:  ValueMatcher{MatchType: MatchTypePresent},
+			value:    "",
+			present:  true,
+			expected: true,
+		},
+		{
+			name:     "absent value never matches",
+			matcher:  ValueMatcher{MatchType: MatchTypePresent},
+			value:    "",
+			present:  false,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.matcher.Matches(tt.value, tt.present))
+		})
+	}
+}
+
+func TestValueMatcherValidate(t *testing.T) {
+	assert.NoError(t, ValueMatcher{MatchType: MatchTypeRegex, Value: `\d+`}.Validate())
+
+	err := ValueMatcher{MatchType: MatchTypeRegex, Value: `(`}.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid regular expression")
+
+	err = ValueMatcher{MatchType: "fuzzy", Value: "foo"}.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid match type: fuzzy")
+}
+
+func TestMatchEndpointWithValueMatchers(t *testing.T) {
+	rm := NewResponseManager()
+
+	endpoints := []EndpointConfiguration{
+		{
+			EndpointID: EndpointID{
+				Path:       "/api/v1/orders",
+				HTTPMethod: "GET",
+			},
+			ResponseBody: "anonymous",
+		},
+		{
+			EndpointID: EndpointID{
+				Path:               "/api/v1/orders",
+				HTTPMethod:         "GET",
+				HeaderMatchers:     map[string]ValueMatcher{"Authorization": {MatchType: MatchTypeRegex, Value: `^Bearer .+$`}},
+				QueryParamMatchers: map[string]ValueMatcher{"page": {MatchType: MatchTypeRegex, Value: `^\d+$`}},
+			},
+			ResponseBody: "authenticated",
+		},
+		{
+			EndpointID: EndpointID{
+				Path:          `^/api/v\d+/products/[a-z]+$`,
+				PathMatchType: MatchTypeRegex,
+				HTTPMethod:    "GET",
+			},
+			ResponseBody: "product",
+		},
+	}
+
+	for _, endpoint := range endpoints {
+		require.NoError(t, rm.AddEndpoint(endpoint))
+	}
+
+	result, err := rm.MatchEndpoint(&EndpointID{
+		Path:               "/api/v1/orders",
+		HTTPMethod:         "GET",
+		HeadersToMatch:     map[string]string{"Authorization": "Bearer token"},
+		QueryParamsToMatch: map[string]string{"page": "12"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "authenticated", result.ResponseBody)
+
+	result, err = rm.MatchEndpoint(&EndpointID{
+		Path:               "/api/v1/orders",
+		HTTPMethod:         "GET",
+		HeadersToMatch:     map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+		QueryParamsToMatch: map[string]string{"page": "12"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "anonymous", result.ResponseBody, "a failing matcher rejects the endpoint")
+
+	result, err = rm.MatchEndpoint(&EndpointID{
+		Path:               "/api/v1/orders",
+		HTTPMethod:         "GET",
+		QueryParamsToMatch: map[string]string{"page": "12"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "anonymous", result.ResponseBody, "a missing header rejects the endpoint")
+
+	result, err = rm.MatchEndpoint(&EndpointID{Path: "/api/v2/products/shoes", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "product", result.ResponseBody)
+
+	_, err = rm.MatchEndpoint(&EndpointID{Path: "/api/v2/products/123", HTTPMethod: "GET"})
+	assert.Error(t, err)
+}
+
+func TestMatchEndpointWithExactPath(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		ID:           "literal",
+		EndpointID:   EndpointID{Path: "/api/{id}/*", PathMatchType: MatchTypeExact, HTTPMethod: "GET"},
+		ResponseBody: "literal",
+	}))
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		EndpointID:   EndpointID{Path: "/api/{id}/{name}", HTTPMethod: "GET"},
+		ResponseBody: "template",
+	}))
+
+	result, err := rm.MatchEndpoint(&EndpointID{Path: "/api/{id}/*", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "literal", result.ResponseBody)
+	assert.Empty(t, result.PathParams)
+
+	result, err = rm.MatchEndpoint(&EndpointID{Path: "/api/7/x", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "template", result.ResponseBody, "an exact path does not expand {id} or *")
+}
+
+func TestAddEndpointCompilesRegularExpressions(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		ID: "orders",
+		EndpointID: EndpointID{
+			Path:           `^/api/v\d+/orders$`,
+			PathMatchType:  MatchTypeRegex,
+			HTTPMethod:     "GET",
+			HeaderMatchers: map[string]ValueMatcher{"Authorization": {MatchType: MatchTypeRegex, Value: `^Bearer .+$`}},
+			BodyMatchers:   []BodyMatcher{{MatchType: BodyMatchTypeRegex, Value: "order"}},
+		},
+	}))
+
+	endpoint, err := rm.GetEndpoint("orders")
+	require.NoError(t, err)
+	assert.NotNil(t, endpoint.EndpointID.pathRegex)
+	assert.NotNil(t, endpoint.EndpointID.HeaderMatchers["Authorization"].regex)
+	assert.NotNil(t, endpoint.EndpointID.BodyMatchers[0].regex)
+}
+
+func TestMatchEndpointRejectsFailingValueMatchers(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{
+			Path:           "/api/v1/orders",
+			HTTPMethod:     "GET",
+			HeaderMatchers: map[string]ValueMatcher{"Authorization": {MatchType: MatchTypePresent}},
+		},
+	}))
+
+	_, err := rm.MatchEndpoint(&EndpointID{Path: "/api/v1/orders", HTTPMethod: "GET"})
+	require.Error(t, err)
+
+	_, err = rm.MatchEndpoint(&EndpointID{
+		Path:           "/api/v1/orders",
+		HTTPMethod:     "GET",
+		HeadersToMatch: map[string]string{"authorization": "anything"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestAddEndpointWithInvalidMatchers(t *testing.T) {
+	rm := NewResponseManager()
+
+	err := rm.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{
+			Path:           "/api/v1/orders",
+			HTTPMethod:     "GET",
+			HeaderMatchers: map[string]ValueMatcher{"Authorization": {MatchType: MatchTypeRegex, Value: "("}},
+		},
+		ResponseBody: "body",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "header Authorization: invalid regular expression")
+
+	err = rm.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{
+			Path:          "/api/v1/orders",
+			PathMatchType: MatchTypePresent,
+			HTTPMethod:    "GET",
+		},
+		ResponseBody: "body",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid path match type: present")
+}
@@ -0,0 +1,118 @@
+package stubserver
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultNamespace is the namespace of requests that do not select one.
+const DefaultNamespace = "default"
+
+// namespace holds the stubs, scenarios, request journal and proxy of one isolated set of tests.
+type namespace struct {
+	responseManager *ResponseManager
+	journal         *RequestJournal
+	proxy           *Proxy
+	contract        *ContractValidator
+	webSocketFrames *WebSocketFrameJournal
+	callbacks       *CallbackJournal
+	callbackSender  *callbackSender
+}
+
+func newNamespace(journalSize int) *namespace {
+	responseManager := NewResponseManager()
+	callbacks := NewCallbackJournal(journalSize)
+
+	return &namespace{
+		responseManager: responseManager,
+		journal:         NewRequestJournal(journalSize),
+		proxy:           NewProxy(responseManager.AddEndpoint),
+		contract:        NewContractValidator(journalSize),
+		webSocketFrames: NewWebSocketFrameJournal(journalSize),
+		callbacks:       callbacks,
+		callbackSender:  newCallbackSender(callbacks),
+	}
+}
+
+// namespaceRegistry keeps the namespaces of the stub server, which are created on first use and
+// live until they are deleted.
+type namespaceRegistry struct {
+	mu          sync.Mutex
+	namespaces  map[string]*namespace
+	journalSize int
+}
+
+func newNamespaceRegistry(journalSize int) *namespaceRegistry {
+	return &namespaceRegistry{namespaces: make(map[string]*namespace), journalSize: journalSize}
+}
+
+// get returns the namespace with the given name, creating it when it does not exist yet.
+func (r *namespaceRegistry) get(name string) *namespace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ns, exists := r.namespaces[name]
+	if !exists {
+		ns = newNamespace(r.journalSize)
+		r.namespaces[name] = ns
+	}
+
+	return ns
+}
+
+// delete removes the namespace with the given name and stops its pending callbacks. It reports
+// whether the namespace existed.
+func (r *namespaceRegistry) delete(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ns, exists := r.namespaces[name]
+	if exists {
+		ns.callbackSender.Close()
+		delete(r.namespaces, name)
+	}
+
+	return exists
+}
+
+// close stops the pending callbacks of every namespace.
+func (r *namespaceRegistry) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ns := range r.namespaces {
+		ns.callbackSender.Close()
+	}
+}
+
+// namespaceName returns the namespace selected by the path parameter of the admin API or the
+// namespace header, falling back to the default namespace.
+func namespaceName(c *gin.Context) string {
+	if name := c.Param("namespace"); name != "" {
+		return name
+	}
+
+	if name := strings.TrimSpace(c.GetHeader(NamespaceHeader)); name != "" {
+		return name
+	}
+
+	return DefaultNamespace
+}
+
+// stripNamespacePrefix removes the namespace prefix from a request path like
+// "/namespaces/{name}/orders". It returns the name and the remaining path when the prefix is present.
+func stripNamespacePrefix(path string) (string, string, bool) {
+	rest, found := strings.CutPrefix(path, NamespacePathPrefix+"/")
+	if !found {
+		return "", path, false
+	}
+
+	name, rest, _ := strings.Cut(rest, "/")
+	if name == "" {
+		return "", path, false
+	}
+
+	return name, "/" + rest, true
+}
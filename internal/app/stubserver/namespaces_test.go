@@ -0,0 +1,44 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripNamespacePrefix(t *testing.T) {
+	tests := []struct {
+		path         string
+		expectedName string
+		expectedPath string
+		expectedOK   bool
+	}{
+		{path: "/namespaces/team-a/orders/1", expectedName: "team-a", expectedPath: "/orders/1", expectedOK: true},
+		{path: "/namespaces/team-a", expectedName: "team-a", expectedPath: "/", expectedOK: true},
+		{path: "/namespaces/", expectedPath: "/namespaces/"},
+		{path: "/namespacesx/team-a/orders", expectedPath: "/namespacesx/team-a/orders"},
+		{path: "/orders", expectedPath: "/orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			name, path, ok := stripNamespacePrefix(tt.path)
+			assert.Equal(t, tt.expectedName, name)
+			assert.Equal(t, tt.expectedPath, path)
+			assert.Equal(t, tt.expectedOK, ok)
+		})
+	}
+}
+
+func TestNamespaceRegistry(t *testing.T) {
+	registry := newNamespaceRegistry(defaultJournalSize)
+
+	teamA := registry.get("team-a")
+	assert.Same(t, teamA, registry.get("team-a"))
+	assert.NotSame(t, teamA, registry.get("team-b"))
+	assert.NotSame(t, teamA.journal, registry.get("team-b").journal)
+
+	assert.True(t, registry.delete("team-a"))
+	assert.False(t, registry.delete("team-a"))
+	assert.NotSame(t, teamA, registry.get("team-a"))
+}
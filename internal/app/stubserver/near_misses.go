@@ -0,0 +1,119 @@
+package stubserver
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// maxNearMisses is the number of closest endpoints that are reported for an unmatched request.
+const maxNearMisses = 3
+
+// NearMiss describes an endpoint that did not match a request, with the criteria that failed.
+type NearMiss struct {
+	EndpointConfiguration
+
+	Mismatches []string
+}
+
+// NearMisses returns the endpoints that come closest to matching the request, ordered by the
+// number of criteria they fail and then by the number of exact values that differ.
+func (rm *ResponseManager) NearMisses(ei *EndpointID) []NearMiss {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	type candidate struct {
+		NearMiss
+
+		failed int
+	}
+
+	candidates := make([]candidate, 0, len(rm.endpoints))
+
+	for endpointID, endpoint := range rm.endpoints {
+		mismatches, failed := rm.explainMismatches(endpointID, &endpoint, ei)
+		if failed > 0 {
+			candidates = append(candidates, candidate{NearMiss{EndpointConfiguration: endpoint, Mismatches: mismatches}, failed})
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(
+			cmp.Compare(a.failed, b.failed),
+			cmp.Compare(len(a.Mismatches), len(b.Mismatches)),
+			strings.Compare(GetID(&a.EndpointID), GetID(&b.EndpointID)),
+		)
+	})
+
+	nearMisses := make([]NearMiss, 0, maxNearMisses)
+	for _, candidate := range candidates[:min(len(candidates), maxNearMisses)] {
+		nearMisses = append(nearMisses, candidate.NearMiss)
+	}
+
+	return nearMisses
+}
+
+// explainMismatches lists the criteria of the endpoint that the request does not satisfy and
+// returns how many there are. The exact query parameter and header values that differ are listed
+// as well, although they do not keep an endpoint from matching. Callers must hold at least the
+// read lock.
+func (rm *ResponseManager) explainMismatches(endpointID string, ec *EndpointConfiguration, ei *EndpointID) ([]string, int) {
+	mismatches := make([]string, 0)
+	mismatch := func(format string, args ...any) {
+		mismatches = append(mismatches, fmt.Sprintf(format, args...))
+	}
+
+	rm.checkEndpoint(endpointID, ec, ei, mismatch)
+	failed := len(mismatches)
+
+	checkValueMatchers("query parameter", exactMatchers(ec.EndpointID.QueryParamsToMatch), ei.QueryParamsToMatch, false, mismatch)
+	checkValueMatchers("header", exactMatchers(ec.EndpointID.HeadersToMatch), ei.HeadersToMatch, true, mismatch)
+
+	return mismatches, failed
+}
+
+// exactMatchers turns exact query parameter or header values into the equivalent matchers.
+func exactMatchers(values map[string]string) map[string]ValueMatcher {
+	matchers := make(map[string]ValueMatcher, len(values))
+	for name, value := range values {
+		matchers[name] = ValueMatcher{MatchType: MatchTypeExact, Value: value}
+	}
+
+	return matchers
+}
+
+func describePath(stub *EndpointID) string {
+	if stub.PathMatchType == "" {
+		return stub.Path
+	}
+
+	return ValueMatcher{MatchType: stub.PathMatchType, Value: stub.Path}.String()
+}
+
+func checkValueMatchers(kind string, matchers map[string]ValueMatcher, values map[string]string, headers bool, mismatch func(string, ...any)) {
+	for _, name := range slices.Sorted(maps.Keys(matchers)) {
+		value, present := lookupValue(values, name, headers)
+
+		switch {
+		case !present:
+			mismatch("%s %s is missing, expected %s", kind, name, matchers[name])
+		case !matchers[name].Matches(value, present):
+			mismatch("%s %s is %s, expected %s", kind, name, value, matchers[name])
+		}
+	}
+}
+
+func checkListMatchers(kind string, matchers map[string]ListMatcher, values map[string][]string, headers bool, mismatch func(string, ...any)) {
+	for _, name := range slices.Sorted(maps.Keys(matchers)) {
+		actual, present := lookupValue(values, name, headers)
+
+		switch {
+		case !present:
+			mismatch("%s %s is missing, expected %s", kind, name, matchers[name])
+		case !matchers[name].Matches(actual):
+			mismatch("%s %s is [%s], expected %s", kind, name, strings.Join(actual, ","), matchers[name])
+		}
+	}
+}
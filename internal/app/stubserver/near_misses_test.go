@@ -0,0 +1,213 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestNearMisses(t *testing.T) {
+	rm := NewResponseManager()
+
+	endpoints := []EndpointConfiguration{
+		{
+			ID: "orders",
+			EndpointID: EndpointID{
+				Path:               "/api/orders",
+				HTTPMethod:         "GET",
+				QueryParamsToMatch: map[string]string{"status": "open"},
+				HeadersToMatch:     map[string]string{"X-Tenant": "acme"},
+			},
+			ResponseBody: "orders",
+		},
+		{
+			ID: "create-order",
+			EndpointID: EndpointID{
+				Path:       "/api/orders",
+				HTTPMethod: "POST",
+				HeaderMatchers: map[string]ValueMatcher{
+					"Authorization": {MatchType: MatchTypePrefix, Value: "Bearer "},
+				},
+			},
+			ResponseBody: "created",
+		},
+		{
+			ID: "users",
+			EndpointID: EndpointID{
+				Path:       "/api/users/{id}",
+				HTTPMethod: "DELETE",
+			},
+			ResponseBody: "deleted",
+		},
+		{
+			ID: "articles",
+			EndpointID: EndpointID{
+				Path:           "/api/articles",
+				HTTPMethod:     "PUT",
+				HeadersToMatch: map[string]string{"X-Tenant": "acme"},
+				QueryParamListMatchers: map[string]ListMatcher{
+					"tag": {MatchType: ListMatchTypeContainsAll, Values: []string{"go"}},
+				},
+			},
+			ResponseBody: "articles",
+		},
+	}
+
+	for _, endpoint := range endpoints {
+		require.NoError(t, rm.AddEndpoint(endpoint))
+	}
+
+	request := EndpointID{
+		Path:               "/api/orders",
+		HTTPMethod:         "PATCH",
+		QueryParamsToMatch: map[string]string{"status": "closed"},
+		HeadersToMatch:     map[string]string{"Authorization": "Basic abc"},
+	}
+
+	nearMisses := rm.NearMisses(&request)
+	require.Len(t, nearMisses, maxNearMisses)
+
+	assert.Equal(t, "orders", nearMisses[0].ID)
+	assert.Equal(t, []string{
+		"method PATCH does not match GET",
+		"query parameter status is closed, expected exact(open)",
+		"header X-Tenant is missing, expected exact(acme)",
+	}, nearMisses[0].Mismatches, "differing exact values are listed after the failed criteria")
+
+	assert.Equal(t, "create-order", nearMisses[1].ID)
+	assert.Equal(t, []string{
+		"method PATCH does not match POST",
+		"header Authorization is Basic abc, expected prefix(Bearer )",
+	}, nearMisses[1].Mismatches)
+
+	assert.Equal(t, "users", nearMisses[2].ID)
+	assert.Equal(t, []string{
+		"method PATCH does not match DELETE",
+		"path /api/orders does not match /api/users/{id}",
+	}, nearMisses[2].Mismatches)
+}
+
+func TestNearMissesListExactValues(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		ID: "orders",
+		EndpointID: EndpointID{
+			Path:               "/api/orders",
+			HTTPMethod:         "POST",
+			QueryParamsToMatch: map[string]string{"status": "open"},
+			HeadersToMatch:     map[string]string{"X-Tenant": "acme"},
+		},
+	}))
+
+	tests := []struct {
+		name     string
+		request  EndpointID
+		expected []string
+	}{
+		{
+			name: "query parameter",
+			request: EndpointID{
+				QueryParamsToMatch: map[string]string{"status": "closed"},
+				HeadersToMatch:     map[string]string{"X-Tenant": "acme"},
+			},
+			expected: []string{"method GET does not match POST", "query parameter status is closed, expected exact(open)"},
+		},
+		{
+			name: "header",
+			request: EndpointID{
+				QueryParamsToMatch: map[string]string{"status": "open"},
+				HeadersToMatch:     map[string]string{"x-tenant": "globex"},
+			},
+			expected: []string{"method GET does not match POST", "header X-Tenant is globex, expected exact(acme)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.request.Path, tt.request.HTTPMethod = "/api/orders", "GET"
+
+			nearMisses := rm.NearMisses(&tt.request)
+			require.Len(t, nearMisses, 1)
+			assert.Equal(t, tt.expected, nearMisses[0].Mismatches)
+		})
+	}
+}
+
+func TestNearMissesAgreeWithMatching(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		ID: "express",
+		EndpointID: EndpointID{
+			Path:           "/api/orders",
+			HTTPMethod:     "POST",
+			HeadersToMatch: map[string]string{"X-Tenant": "acme"},
+			HeaderMatchers: map[string]ValueMatcher{"Authorization": {MatchType: MatchTypePresent}},
+			BodyMatchers:   []BodyMatcher{{MatchType: BodyMatchTypeJSONSubset, Value: `{"type":"express"}`}},
+		},
+	}))
+
+	request := EndpointID{
+		Path:           "/api/orders",
+		HTTPMethod:     "POST",
+		HeadersToMatch: map[string]string{"Authorization": "Bearer token"},
+		Body:           []byte(`{"type":"standard"}`),
+	}
+
+	_, err := rm.MatchEndpoint(&request)
+	require.Error(t, err)
+
+	nearMisses := rm.NearMisses(&request)
+	require.Len(t, nearMisses, 1)
+	assert.Equal(t, []string{
+		`body does not match jsonSubset({"type":"express"})`,
+		"header X-Tenant is missing, expected exact(acme)",
+	}, nearMisses[0].Mismatches)
+
+	request.Body = []byte(`{"type":"express"}`)
+
+	_, err = rm.MatchEndpoint(&request)
+	require.NoError(t, err, "the missing exact header value does not reject the endpoint")
+	assert.Empty(t, rm.NearMisses(&request))
+}
+
+func TestNearMissesExplainScenarioAndSequence(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		ID: "checkout",
+		EndpointID: EndpointID{
+			Path:                  "/api/checkout",
+			HTTPMethod:            "POST",
+			ScenarioName:          "cart",
+			RequiredScenarioState: "item added",
+		},
+		ResponseBody: "ok",
+	}))
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		ID:             "token",
+		EndpointID:     EndpointID{Path: "/api/token", HTTPMethod: "POST"},
+		Responses:      []Response{{Body: "token"}},
+		SequencePolicy: SequencePolicyExhaust,
+	}))
+
+	token := EndpointID{Path: "/api/token", HTTPMethod: "POST"}
+	_, err := rm.ServeEndpoint(&token)
+	require.NoError(t, err)
+
+	nearMisses := rm.NearMisses(&token)
+	require.NotEmpty(t, nearMisses)
+	assert.Equal(t, "token", nearMisses[0].ID)
+	assert.Equal(t, []string{"response sequence is exhausted"}, nearMisses[0].Mismatches)
+
+	checkout := EndpointID{Path: "/api/checkout", HTTPMethod: "POST"}
+
+	nearMisses = rm.NearMisses(&checkout)
+	require.NotEmpty(t, nearMisses)
+	assert.Equal(t, "checkout", nearMisses[0].ID)
+	assert.Equal(t, []string{"scenario cart is in state Started instead of item added"}, nearMisses[0].Mismatches)
+}
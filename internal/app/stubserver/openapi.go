@@ -0,0 +1,327 @@
+package stubserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxSchemaDepth limits how deep nested and recursive schemas are synthesised.
+const maxSchemaDepth = 8
+
+// LoadOpenAPISpec adds an endpoint for every operation of the OpenAPI 3 document in the JSON or
+// YAML file to the default namespace. Operations that cannot be stubbed are skipped with a warning.
+func (s *Server) LoadOpenAPISpec(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read OpenAPI spec: %w", err)
+	}
+
+	_, skipped, err := s.namespaces.get(DefaultNamespace).importOpenAPISpec(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, reason := range skipped {
+		log.WithFields(log.Fields{"file": path}).Warnf("skipped OpenAPI operation: %s", reason)
+	}
+
+	return nil
+}
+
+// importOpenAPISpec adds an endpoint for every operation of the OpenAPI 3 document and returns
+// their stub IDs, together with the reasons for skipping the operations that cannot be stubbed.
+// The endpoints are added all at once: when any of them conflicts with an existing stub, none is
+// added.
+func (ns *namespace) importOpenAPISpec(data []byte) ([]string, []string, error) {
+	configs, skipped, err := endpointsFromOpenAPISpec(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids, err := ns.responseManager.CreateEndpoints(configs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ids, skipped, nil
+}
+
+// endpointsFromOpenAPISpec creates an endpoint configuration for every operation of the OpenAPI 3
+// document. The path of the first server is prepended to the paths of the operations, and the
+// operation ID, if any, becomes the stub ID. Operations that do not result in a valid endpoint,
+// such as HEAD operations, are skipped and the reasons are returned.
+func endpointsFromOpenAPISpec(data []byte) ([]EndpointConfiguration, []string, error) {
+	doc, err := loadOpenAPISpec(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	basePath := openAPIBasePath(doc.Servers)
+	configs := make([]EndpointConfiguration, 0)
+	skipped := make([]string, 0)
+
+	for _, path := range slices.Sorted(maps.Keys(doc.Paths.Map())) {
+		operations := doc.Paths.Value(path).Operations()
+
+		for _, method := range slices.Sorted(maps.Keys(operations)) {
+			config, err := openAPIEndpoint(basePath+path, method, operations[method])
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s %s: %s", method, path, err))
+
+				continue
+			}
+
+			configs = append(configs, config)
+		}
+	}
+
+	return configs, skipped, nil
+}
+
+// openAPIEndpoint creates a valid endpoint configuration for the operation.
+func openAPIEndpoint(path, method string, operation *openapi3.Operation) (EndpointConfiguration, error) {
+	statusCode, response := openAPIResponse(operation.Responses)
+
+	config := EndpointConfiguration{
+		ID:                 operation.OperationID,
+		EndpointID:         EndpointID{Path: path, HTTPMethod: method},
+		ResponseStatusCode: statusCode,
+	}
+
+	if response != nil {
+		var err error
+
+		config.ResponseHeaders, config.ResponseBody, err = openAPIResponseBody(response.Content)
+		if err != nil {
+			return EndpointConfiguration{}, err
+		}
+	}
+
+	err := ValidateEndpoint(config)
+	if err != nil {
+		return EndpointConfiguration{}, err
+	}
+
+	return config, nil
+}
+
+// loadOpenAPISpec parses and validates the OpenAPI 3 document in JSON or YAML format.
+func loadOpenAPISpec(data []byte) (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	err = doc.Validate(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	return doc, nil
+}
+
+func openAPIBasePath(servers openapi3.Servers) string {
+	if len(servers) == 0 {
+		return ""
+	}
+
+	serverURL := servers[0].URL
+	for name, variable := range servers[0].Variables {
+		serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", variable.Default)
+	}
+
+	parsedURL, err := url.Parse(serverURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(parsedURL.Path, "/")
+}
+
+// openAPIResponse selects the response of an operation to stub: the success response with the
+// lowest status code, falling back to the default response and then to any other response.
+func openAPIResponse(responses *openapi3.Responses) (int, *openapi3.Response) {
+	var (
+		selectedCode     int
+		selectedResponse *openapi3.Response
+	)
+
+	for code, response := range responses.Map() {
+		statusCode, err := strconv.Atoi(strings.ReplaceAll(strings.ToUpper(code), "X", "0"))
+		if err != nil {
+			continue
+		}
+
+		if selectedResponse == nil || isSuccess(statusCode) != isSuccess(selectedCode) && isSuccess(statusCode) ||
+			isSuccess(statusCode) == isSuccess(selectedCode) && statusCode < selectedCode {
+			selectedCode, selectedResponse = statusCode, response.Value
+		}
+	}
+
+	if isSuccess(selectedCode) {
+		return selectedCode, selectedResponse
+	}
+
+	if response := responses.Default(); response != nil {
+		return http.StatusOK, response.Value
+	}
+
+	if selectedResponse != nil {
+		return selectedCode, selectedResponse
+	}
+
+	return http.StatusOK, nil
+}
+
+func isSuccess(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+}
+
+// openAPIResponseBody returns the content type header and body of the response, preferring JSON
+// content. The body is the example of the content or is synthesised from its schema.
+func openAPIResponseBody(content openapi3.Content) (map[string]string, string, error) {
+	if len(content) == 0 {
+		return nil, "", nil
+	}
+
+	contentType := "application/json"
+	if _, exists := content[contentType]; !exists {
+		contentType = slices.Sorted(maps.Keys(content))[0]
+	}
+
+	mediaType := content[contentType]
+	headers := map[string]string{contentTypeHeader: contentType}
+
+	example := openAPIExample(mediaType)
+
+	if text, ok := example.(string); ok && !strings.Contains(contentType, "json") {
+		return headers, text, nil
+	}
+
+	body, err := json.Marshal(example)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to marshal example: %w", err)
+	}
+
+	return headers, string(body), nil
+}
+
+func openAPIExample(mediaType *openapi3.MediaType) any {
+	if mediaType.Example != nil {
+		return mediaType.Example
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(mediaType.Examples)) {
+		if example := mediaType.Examples[name].Value; example != nil && example.Value != nil {
+			return example.Value
+		}
+	}
+
+	if mediaType.Schema == nil {
+		return nil
+	}
+
+	return exampleFromSchema(mediaType.Schema.Value, 0)
+}
+
+// exampleFromSchema synthesises a value that conforms to the schema, using the examples, defaults
+// and enums of the schema where possible.
+//
+//nolint:cyclop
+func exampleFromSchema(schema *openapi3.Schema, depth int) any {
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := make(map[string]any)
+
+		for _, part := range schema.AllOf {
+			if value, ok := exampleFromSchema(part.Value, depth+1).(map[string]any); ok {
+				maps.Copy(merged, value)
+			}
+		}
+
+		return merged
+	case len(schema.OneOf) > 0:
+		return exampleFromSchema(schema.OneOf[0].Value, depth+1)
+	case len(schema.AnyOf) > 0:
+		return exampleFromSchema(schema.AnyOf[0].Value, depth+1)
+	}
+
+	switch {
+	case schema.Type.Is(openapi3.TypeArray):
+		if schema.Items == nil {
+			return []any{}
+		}
+
+		return []any{exampleFromSchema(schema.Items.Value, depth+1)}
+	case schema.Type.Is(openapi3.TypeString):
+		return exampleString(schema.Format)
+	case schema.Type.Is(openapi3.TypeInteger):
+		if schema.Min != nil {
+			return int64(*schema.Min)
+		}
+
+		return 0
+	case schema.Type.Is(openapi3.TypeNumber):
+		if schema.Min != nil {
+			return *schema.Min
+		}
+
+		return 0.0
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return true
+	}
+
+	object := make(map[string]any, len(schema.Properties))
+
+	for name, property := range schema.Properties {
+		if property.Value != nil && property.Value.WriteOnly {
+			continue
+		}
+
+		object[name] = exampleFromSchema(property.Value, depth+1)
+	}
+
+	return object
+}
+
+func exampleString(format string) string {
+	switch format {
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+
+	return "string"
+}
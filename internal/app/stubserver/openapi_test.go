@@ -0,0 +1,224 @@
+package stubserver
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petStoreSpec = `
+openapi: 3.0.3
+info:
+  title: Pet store
+  version: 1.0.0
+servers:
+  - url: https://{environment}.example.com/v1
+    variables:
+      environment:
+        default: api
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: The pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+    post:
+      responses:
+        "400":
+          description: Invalid pet
+        "201":
+          description: The created pet
+          content:
+            application/json:
+              example: {"id": 7, "name": "Rex"}
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        default:
+          description: The pet
+          content:
+            text/plain:
+              examples:
+                rex:
+                  value: Rex
+    delete:
+      operationId: deletePet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "204":
+          description: Deleted
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id:
+          type: integer
+          minimum: 1
+        name:
+          type: string
+        born:
+          type: string
+          format: date
+        status:
+          type: string
+          enum: [available, sold]
+        password:
+          type: string
+          writeOnly: true
+`
+
+//nolint:funlen
+func TestEndpointsFromOpenAPISpec(t *testing.T) {
+	configs, skipped, err := endpointsFromOpenAPISpec([]byte(petStoreSpec))
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+
+	expected := []EndpointConfiguration{
+		{
+			ID:                 "listPets",
+			EndpointID:         EndpointID{Path: "/v1/pets", HTTPMethod: http.MethodGet},
+			ResponseHeaders:    map[string]string{contentTypeHeader: "application/json"},
+			ResponseBody:       `[{"born":"2024-01-01","id":1,"name":"string","status":"available"}]`,
+			ResponseStatusCode: http.StatusOK,
+		},
+		{
+			EndpointID:         EndpointID{Path: "/v1/pets", HTTPMethod: http.MethodPost},
+			ResponseHeaders:    map[string]string{contentTypeHeader: "application/json"},
+			ResponseBody:       `{"id":7,"name":"Rex"}`,
+			ResponseStatusCode: http.StatusCreated,
+		},
+		{
+			ID:                 "deletePet",
+			EndpointID:         EndpointID{Path: "/v1/pets/{id}", HTTPMethod: http.MethodDelete},
+			ResponseStatusCode: http.StatusNoContent,
+		},
+		{
+			ID:                 "getPet",
+			EndpointID:         EndpointID{Path: "/v1/pets/{id}", HTTPMethod: http.MethodGet},
+			ResponseHeaders:    map[string]string{contentTypeHeader: "text/plain"},
+			ResponseBody:       "Rex",
+			ResponseStatusCode: http.StatusOK,
+		},
+	}
+	assert.Equal(t, expected, configs)
+}
+
+func TestEndpointsFromInvalidOpenAPISpec(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{name: "not a document", spec: "openapi: ["},
+		{name: "missing info", spec: `{"openapi": "3.0.3", "paths": {}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := endpointsFromOpenAPISpec([]byte(tt.spec))
+			assert.ErrorContains(t, err, "invalid OpenAPI spec")
+		})
+	}
+}
+
+func TestExampleFromRecursiveSchema(t *testing.T) {
+	node := openapi3.NewObjectSchema()
+	node.Properties = openapi3.Schemas{"parent": &openapi3.SchemaRef{Value: node}}
+
+	example := exampleFromSchema(node, 0)
+
+	for range maxSchemaDepth {
+		object, ok := example.(map[string]any)
+		require.True(t, ok)
+
+		example = object["parent"]
+	}
+
+	assert.Equal(t, map[string]any{"parent": nil}, example)
+}
+
+func TestImportOpenAPISpec(t *testing.T) {
+	server := NewServer()
+	dir := t.TempDir()
+
+	writeDefinitionFile(t, dir, "petstore.yaml", petStoreSpec)
+
+	err := server.LoadOpenAPISpec(filepath.Join(dir, "petstore.yaml"))
+	require.NoError(t, err)
+
+	ns := server.namespaces.get(DefaultNamespace)
+
+	_, err = ns.responseManager.GetEndpoint("getPet")
+	require.NoError(t, err)
+
+	_, _, err = ns.importOpenAPISpec([]byte(petStoreSpec))
+	assert.ErrorContains(t, err, "endpoint already exists")
+
+	err = server.LoadOpenAPISpec(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "unable to read OpenAPI spec")
+}
+
+const headSpec = `
+openapi: 3.0.3
+info:
+  title: Health
+  version: 1.0.0
+paths:
+  /health:
+    get:
+      operationId: health
+      responses:
+        "204":
+          description: Healthy
+    head:
+      operationId: healthHead
+      responses:
+        "204":
+          description: Healthy
+`
+
+func TestImportOpenAPISpecSkipsInvalidOperations(t *testing.T) {
+	ns := newNamespace(0)
+
+	ids, skipped, err := ns.importOpenAPISpec([]byte(headSpec))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"health"}, ids)
+	assert.Equal(t, []string{"HEAD /health: invalid HTTP method: HEAD"}, skipped)
+}
+
+func TestImportOpenAPISpecIsAtomic(t *testing.T) {
+	ns := newNamespace(0)
+
+	_, err := ns.responseManager.CreateEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{Path: "/v1/pets/{id}", HTTPMethod: http.MethodGet},
+	})
+	require.NoError(t, err)
+
+	_, _, err = ns.importOpenAPISpec([]byte(petStoreSpec))
+	require.ErrorContains(t, err, "GET /v1/pets/{id}: endpoint already exists")
+
+	assert.Len(t, ns.responseManager.GetAllEndpointConfigurations(), 1)
+}
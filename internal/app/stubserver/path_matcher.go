@@ -0,0 +1,120 @@
+package stubserver
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	singleSegmentWildcard = "*"
+	multiSegmentWildcard  = "**"
+)
+
+// Specificity weights used to prefer literal path segments over templates and wildcards.
+const (
+	literalSegmentWeight  = 2
+	variableSegmentWeight = 1
+)
+
+// validatePathTemplate checks that template variables in the path are well-formed and unique.
+func validatePathTemplate(path string) error {
+	seen := make(map[string]bool)
+
+	for _, segment := range splitPath(path) {
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+
+		if !isPathVariable(segment) {
+			return fmt.Errorf("invalid path template segment: %s", segment)
+		}
+
+		name := pathVariableName(segment)
+		if seen[name] {
+			return fmt.Errorf("duplicate path variable: %s", name)
+		}
+
+		seen[name] = true
+	}
+
+	return nil
+}
+
+// matchPath matches a request path against a stub path. The stub path may contain
+// variables like "/users/{id}", "*" to match exactly one segment and "**" to match
+// zero or more segments. The values of the variables are returned on a match.
+func matchPath(pattern, path string) (map[string]string, bool) {
+	params := make(map[string]string)
+
+	if !matchSegments(splitPath(pattern), splitPath(path), params) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+func matchSegments(patternSegments, pathSegments []string, params map[string]string) bool {
+	for i, segment := range patternSegments {
+		if segment == multiSegmentWildcard {
+			rest := patternSegments[i+1:]
+
+			for j := i; j <= len(pathSegments); j++ {
+				if matchSegments(rest, pathSegments[j:], params) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if i >= len(pathSegments) {
+			return false
+		}
+
+		switch {
+		case segment == singleSegmentWildcard:
+		case isPathVariable(segment):
+			params[pathVariableName(segment)] = pathSegments[i]
+		case segment != pathSegments[i]:
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
+}
+
+// pathSpecificity scores a stub path so that literal segments outweigh variables and
+// wildcards, which ensures that "/users/me" is preferred over "/users/{id}".
+func pathSpecificity(pattern string) int {
+	specificity := 0
+
+	for _, segment := range splitPath(pattern) {
+		switch {
+		case segment == multiSegmentWildcard:
+		case segment == singleSegmentWildcard || isPathVariable(segment):
+			specificity += variableSegmentWeight
+		default:
+			specificity += literalSegmentWeight
+		}
+	}
+
+	return specificity
+}
+
+func isPathVariable(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") &&
+		!strings.ContainsAny(segment[1:len(segment)-1], "{}")
+}
+
+func pathVariableName(segment string) string {
+	return segment[1 : len(segment)-1]
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return []string{}
+	}
+
+	return strings.Split(trimmed, "/")
+}
@@ -0,0 +1,112 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:funlen
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		pattern        string
+		path           string
+		expectedMatch  bool
+		expectedParams map[string]string
+	}{
+		{
+			name:           "exact path",
+			pattern:        "/api/v1/users",
+			path:           "/api/v1/users",
+			expectedMatch:  true,
+			expectedParams: map[string]string{},
+		},
+		{
+			name:          "different path",
+			pattern:       "/api/v1/users",
+			path:          "/api/v1/orders",
+			expectedMatch: false,
+		},
+		{
+			name:           "single variable",
+			pattern:        "/users/{id}",
+			path:           "/users/42",
+			expectedMatch:  true,
+			expectedParams: map[string]string{"id": "42"},
+		},
+		{
+			name:           "multiple variables",
+			pattern:        "/users/{userId}/orders/{orderId}",
+			path:           "/users/1/orders/abc",
+			expectedMatch:  true,
+			expectedParams: map[string]string{"userId": "1", "orderId": "abc"},
+		},
+		{
+			name:          "variable does not match multiple segments",
+			pattern:       "/users/{id}",
+			path:          "/users/1/orders",
+			expectedMatch: false,
+		},
+		{
+			name:           "single segment wildcard",
+			pattern:        "/users/*/orders",
+			path:           "/users/1/orders",
+			expectedMatch:  true,
+			expectedParams: map[string]string{},
+		},
+		{
+			name:           "multi segment wildcard",
+			pattern:        "/files/**",
+			path:           "/files/a/b/c.txt",
+			expectedMatch:  true,
+			expectedParams: map[string]string{},
+		},
+		{
+			name:           "multi segment wildcard matches zero segments",
+			pattern:        "/files/**",
+			path:           "/files",
+			expectedMatch:  true,
+			expectedParams: map[string]string{},
+		},
+		{
+			name:           "multi segment wildcard followed by variable",
+			pattern:        "/files/**/{name}",
+			path:           "/files/a/b/c.txt",
+			expectedMatch:  true,
+			expectedParams: map[string]string{"name": "c.txt"},
+		},
+		{
+			name:          "multi segment wildcard with non-matching suffix",
+			pattern:       "/files/**/download",
+			path:          "/files/a/b/c.txt",
+			expectedMatch: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, ok := matchPath(tt.pattern, tt.path)
+			assert.Equal(t, tt.expectedMatch, ok)
+			assert.Equal(t, tt.expectedParams, params)
+		})
+	}
+}
+
+func TestValidatePathTemplate(t *testing.T) {
+	assert.NoError(t, validatePathTemplate("/users/{id}/orders/{orderId}"))
+	assert.NoError(t, validatePathTemplate("/files/**"))
+
+	err := validatePathTemplate("/users/{id")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid path template segment")
+
+	err = validatePathTemplate("/users/{id}/orders/{id}")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate path variable: id")
+}
+
+func TestPathSpecificity(t *testing.T) {
+	assert.Greater(t, pathSpecificity("/users/me"), pathSpecificity("/users/{id}"))
+	assert.Greater(t, pathSpecificity("/users/{id}"), pathSpecificity("/users/**"))
+}
@@ -0,0 +1,182 @@
+package stubserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ProxyConfiguration represents the upstream that unmatched requests are forwarded to.
+type ProxyConfiguration struct {
+	UpstreamURL string
+	// Record turns every forwarded exchange into a new endpoint configuration.
+	Record bool
+}
+
+// Proxy forwards requests that did not match any endpoint to an upstream and optionally records
+// the exchanges as endpoint configurations.
+type Proxy struct {
+	mu       sync.RWMutex
+	config   ProxyConfiguration
+	upstream *url.URL
+	recorder func(EndpointConfiguration) error
+}
+
+// NewProxy creates a new, disabled instance of Proxy that passes recorded endpoint configurations to the recorder.
+func NewProxy(recorder func(EndpointConfiguration) error) *Proxy {
+	return &Proxy{recorder: recorder}
+}
+
+// Configure enables the proxy with the given configuration.
+func (p *Proxy) Configure(config ProxyConfiguration) error {
+	upstream, err := url.Parse(config.UpstreamURL)
+	if err != nil {
+		return fmt.Errorf("invalid upstream URL: %w", err)
+	}
+
+	if upstream.Scheme != "http" && upstream.Scheme != "https" || upstream.Host == "" {
+		return fmt.Errorf("invalid upstream URL: %s", config.UpstreamURL)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.config = config
+	p.upstream = upstream
+
+	return nil
+}
+
+// Configuration returns the current configuration of the proxy, which has an empty upstream URL
+// when the proxy is disabled.
+func (p *Proxy) Configuration() ProxyConfiguration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.config
+}
+
+// Disable stops forwarding requests.
+func (p *Proxy) Disable() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.config = ProxyConfiguration{}
+	p.upstream = nil
+}
+
+// Forward forwards the request, whose body has already been read, to the upstream. It returns
+// false without writing a response when the proxy is disabled.
+func (p *Proxy) Forward(w http.ResponseWriter, r *http.Request, body []byte) bool {
+	p.mu.RLock()
+	upstream, record := p.upstream, p.config.Record
+	p.mu.RUnlock()
+
+	if upstream == nil {
+		return false
+	}
+
+	reverseProxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+
+			if record {
+				// Let the transport negotiate compression, so the recorded body is decoded.
+				pr.Out.Header.Del("Accept-Encoding")
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.WithError(err).WithFields(log.Fields{"urlPath": r.URL.Path}).Error("unable to forward request")
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	if record {
+		reverseProxy.ModifyResponse = func(resp *http.Response) error {
+			return p.record(r, body, resp)
+		}
+	}
+
+	reverseProxy.ServeHTTP(w, r)
+
+	return true
+}
+
+func (p *Proxy) record(r *http.Request, body []byte, resp *http.Response) error {
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read upstream response: %w", err)
+	}
+
+	err = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("unable to close upstream response: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewBuffer(responseBody))
+
+	err = p.recorder(recordedEndpoint(r, body, resp, responseBody))
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{"urlPath": r.URL.Path}).Warn("unable to record endpoint")
+	}
+
+	return nil
+}
+
+// recordedEndpoint builds an endpoint configuration that matches the request and serves the
+// upstream response. The path is matched literally, so braces and asterisks in it are no template
+// or wildcard. Only the first value of a repeated response header is kept.
+func recordedEndpoint(r *http.Request, body []byte, resp *http.Response, responseBody []byte) EndpointConfiguration {
+	endpointID := EndpointID{
+		Path:          r.URL.Path,
+		PathMatchType: MatchTypeExact,
+		HTTPMethod:    r.Method,
+	}
+
+	if query := r.URL.Query(); len(query) > 0 {
+		// Exact values only make a stub preferred, so matchers are used to keep a recording from
+		// answering requests with other query parameters.
+		endpointID.QueryParamMatchers = make(map[string]ValueMatcher, len(query))
+
+		for name, value := range firstValues(query) {
+			endpointID.QueryParamMatchers[name] = ValueMatcher{MatchType: MatchTypeExact, Value: value}
+		}
+	}
+
+	if len(body) > 0 {
+		matchType := BodyMatchTypeExact
+		if json.Valid(body) {
+			matchType = BodyMatchTypeJSON
+		}
+
+		endpointID.BodyMatchers = []BodyMatcher{{MatchType: matchType, Value: string(body)}}
+	}
+
+	headers := firstValues(resp.Header)
+	delete(headers, "Content-Length")
+	delete(headers, "Date")
+
+	config := EndpointConfiguration{
+		EndpointID:         endpointID,
+		ResponseHeaders:    headers,
+		ResponseStatusCode: resp.StatusCode,
+	}
+
+	if utf8.Valid(responseBody) {
+		config.ResponseBody = string(responseBody)
+	} else {
+		config.ResponseBodyBase64 = base64.StdEncoding.EncodeToString(responseBody)
+	}
+
+	return config
+}
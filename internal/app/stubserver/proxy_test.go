@@ -0,0 +1,76 @@
+package stubserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyConfigure(t *testing.T) {
+	proxy := NewProxy(func(EndpointConfiguration) error { return nil })
+
+	assert.Empty(t, proxy.Configuration().UpstreamURL)
+	assert.False(t, proxy.Forward(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api", nil), nil))
+
+	err := proxy.Configure(ProxyConfiguration{UpstreamURL: "ftp://example.com"})
+	assert.ErrorContains(t, err, "invalid upstream URL")
+
+	err = proxy.Configure(ProxyConfiguration{UpstreamURL: "https://staging.example.com", Record: true})
+	assert.NoError(t, err)
+	assert.Equal(t, ProxyConfiguration{UpstreamURL: "https://staging.example.com", Record: true}, proxy.Configuration())
+
+	proxy.Disable()
+	assert.Empty(t, proxy.Configuration().UpstreamURL)
+}
+
+func TestRecordedEndpoint(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/api/orders?source=web", nil)
+	response := &http.Response{
+		StatusCode: http.StatusCreated,
+		Header: http.Header{
+			"Content-Type":   []string{"application/json"},
+			"Content-Length": []string{"8"},
+			"Date":           []string{"Mon, 01 Jan 2024 12:00:00 GMT"},
+		},
+	}
+
+	config := recordedEndpoint(request, []byte(`{"item":"book"}`), response, []byte(`{"id":1}`))
+	assert.Equal(t, "/api/orders", config.EndpointID.Path)
+	assert.Equal(t, MatchTypeExact, config.EndpointID.PathMatchType)
+	assert.Equal(t, http.MethodPost, config.EndpointID.HTTPMethod)
+	assert.Equal(t, map[string]ValueMatcher{"source": {MatchType: MatchTypeExact, Value: "web"}}, config.EndpointID.QueryParamMatchers)
+	assert.Equal(t, []BodyMatcher{{MatchType: BodyMatchTypeJSON, Value: `{"item":"book"}`}}, config.EndpointID.BodyMatchers)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, config.ResponseHeaders)
+	assert.Equal(t, `{"id":1}`, config.ResponseBody)
+	assert.Equal(t, http.StatusCreated, config.ResponseStatusCode)
+	assert.NoError(t, ValidateEndpoint(config))
+
+	config = recordedEndpoint(httptest.NewRequest(http.MethodDelete, "/api/orders/1", nil), nil, &http.Response{StatusCode: http.StatusNoContent}, nil)
+	assert.Nil(t, config.EndpointID.QueryParamMatchers)
+	assert.Nil(t, config.EndpointID.BodyMatchers)
+	assert.Empty(t, config.ResponseBody)
+	assert.Equal(t, http.StatusNoContent, config.ResponseStatusCode)
+	assert.NoError(t, ValidateEndpoint(config))
+
+	config = recordedEndpoint(httptest.NewRequest(http.MethodGet, "/logo.png", nil), nil, &http.Response{StatusCode: http.StatusOK}, []byte{0x89, 'P', 'N', 'G', 0xff})
+	assert.Empty(t, config.ResponseBody)
+	assert.Equal(t, "iVBOR/8=", config.ResponseBodyBase64)
+	assert.NoError(t, ValidateEndpoint(config))
+}
+
+func TestRecordedEndpointMatchesPathLiterally(t *testing.T) {
+	rm := NewResponseManager()
+
+	request := httptest.NewRequest(http.MethodGet, "/files/*", nil)
+	require.NoError(t, rm.AddEndpoint(recordedEndpoint(request, nil, &http.Response{StatusCode: http.StatusOK}, []byte("file"))))
+
+	result, err := rm.MatchEndpoint(&EndpointID{Path: "/files/*", HTTPMethod: http.MethodGet})
+	require.NoError(t, err)
+	assert.Equal(t, "file", result.ResponseBody)
+
+	_, err = rm.MatchEndpoint(&EndpointID{Path: "/files/other", HTTPMethod: http.MethodGet})
+	assert.Error(t, err, "a recorded asterisk is no wildcard")
+}
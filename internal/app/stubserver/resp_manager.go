@@ -1,7 +1,9 @@
 package stubserver
 
 import (
+	"cmp"
 	"fmt"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
@@ -12,29 +14,135 @@ import (
 type ResponseManager struct {
 	mu        sync.RWMutex
 	endpoints map[string]EndpointConfiguration
+	sequences map[string]*sequenceState
+	scenarios map[string]string
+
+	// addedOrder records, per stub ID, when the stub was added, to prefer recently added stubs.
+	addedOrder map[string]uint64
+	lastAdded  uint64
 }
 
 // NewResponseManager creates a new instance of ResponseManager.
 func NewResponseManager() *ResponseManager {
 	return &ResponseManager{
-		endpoints: make(map[string]EndpointConfiguration),
+		endpoints:  make(map[string]EndpointConfiguration),
+		sequences:  make(map[string]*sequenceState),
+		scenarios:  make(map[string]string),
+		addedOrder: make(map[string]uint64),
 	}
 }
 
+// AnyHTTPMethod is the HTTP method of an endpoint that matches requests with any method.
+const AnyHTTPMethod = "ANY"
+
 // EndpointID represents a unique identifier for an endpoint.
 type EndpointID struct {
 	Path               string
 	HTTPMethod         string
 	QueryParamsToMatch map[string]string
 	HeadersToMatch     map[string]string
+
+	// PathMatchType determines how Path is matched. When empty, Path is treated as a template; the
+	// exact match type compares Path as a literal string.
+	PathMatchType      MatchType
+	QueryParamMatchers map[string]ValueMatcher
+	HeaderMatchers     map[string]ValueMatcher
+	BodyMatchers       []BodyMatcher
+
+	// QueryParamListMatchers and HeaderListMatchers match every value of a repeated query
+	// parameter or header. Header names are compared case-insensitively.
+	QueryParamListMatchers map[string]ListMatcher
+	HeaderListMatchers     map[string]ListMatcher
+
+	// ClientCertSubjectMatcher optionally matches the subject of the client certificate, like
+	// "CN=orders,O=Acme".
+	ClientCertSubjectMatcher *ValueMatcher
+
+	// WebSocket restricts the endpoint to requests that upgrade the connection to WebSocket. For an
+	// incoming request, it reports whether the request asks for the upgrade.
+	WebSocket bool
+
+	// GRPC restricts the endpoint to calls of the gRPC server, where Path is the full method name.
+	// For an incoming request, it reports whether the request is a gRPC call.
+	GRPC bool
+
+	// ScenarioName optionally ties the endpoint to a scenario. The endpoint then only matches when
+	// the scenario is in RequiredScenarioState, if set.
+	ScenarioName          string
+	RequiredScenarioState string
+
+	// QueryParams, Headers, ClientCertSubject and Body contain every query parameter value, every
+	// header value, the client certificate subject and the raw body when the EndpointID represents
+	// an incoming request.
+	QueryParams       map[string][]string
+	Headers           map[string][]string
+	ClientCertSubject string
+	Body              []byte
+
+	// pathRegex is the compiled Path of the regex path match type, see compileMatchers.
+	pathRegex *regexp.Regexp
 }
 
 // EndpointConfiguration represents the configuration for a stub endpoint.
 type EndpointConfiguration struct {
+	// ID identifies the stub across updates. A random ID is generated when it is not set.
+	ID                 string
 	EndpointID         EndpointID
 	ResponseHeaders    map[string]string
 	ResponseBody       string
 	ResponseStatusCode int
+
+	// ResponseBodyBase64 and ResponseBodyFile are alternatives to ResponseBody for binary content,
+	// see Response.
+	ResponseBodyBase64 string
+	ResponseBodyFile   string
+
+	// ResponseTemplating enables Go templates in the response body, headers and status code,
+	// see TemplateData for the request data that is available.
+	ResponseTemplating         bool
+	ResponseStatusCodeTemplate string
+
+	// Responses optionally defines a sequence of responses that is served on successive calls,
+	// in which case the single response above is ignored.
+	Responses      []Response
+	SequencePolicy SequencePolicy
+
+	// NewScenarioState is the state the scenario of the endpoint moves to when the endpoint is served.
+	NewScenarioState string
+
+	// Fault optionally delays the response or makes serving it fail.
+	Fault *Fault
+
+	// Stream optionally replaces the response body with chunks or events that are written over time.
+	Stream *Stream
+
+	// WebSocket is the conversation of an endpoint that upgrades requests to WebSocket, in which
+	// case EndpointID.WebSocket must be set as well.
+	WebSocket *WebSocket
+
+	// GRPC is the status, trailers and messages of an endpoint that stubs a gRPC method, in which
+	// case EndpointID.GRPC must be set as well.
+	GRPC *GRPC
+
+	// Callbacks are sent in the background once the response has started: after a plain response
+	// was flushed, after the headers of a stream, after the WebSocket upgrade, and after the first
+	// message of a gRPC call or, without messages, when the call ends. They are not sent when a
+	// fault fails the response.
+	Callbacks []Callback
+
+	// Priority decides which endpoint serves a request that several endpoints match: the highest
+	// priority wins. Catch-all endpoints can use a negative priority to act as a default.
+	Priority int
+}
+
+// MatchResult represents an endpoint configuration that matched a request.
+type MatchResult struct {
+	EndpointConfiguration
+
+	// PathParams contains the values of the path template variables, e.g. "id" for "/users/{id}".
+	PathParams map[string]string
+	// Response is the response to serve for the request.
+	Response Response
 }
 
 // GetID generates a unique ID for the endpoint based on its path, method, headers, and query parameters.
@@ -64,20 +172,113 @@ func GetID(ei *EndpointID) string {
 		builder.WriteString(fmt.Sprintf(":%s=%s", key, ei.QueryParamsToMatch[key]))
 	}
 
+	if ei.PathMatchType != "" {
+		builder.WriteString(fmt.Sprintf(":path~%s", ei.PathMatchType))
+	}
+
+	writeMatchers(&builder, ei.HeaderMatchers)
+	writeMatchers(&builder, ei.QueryParamMatchers)
+	writeMatchers(&builder, ei.HeaderListMatchers)
+	writeMatchers(&builder, ei.QueryParamListMatchers)
+
+	if ei.ClientCertSubjectMatcher != nil {
+		builder.WriteString(fmt.Sprintf(":clientcert~%s", ei.ClientCertSubjectMatcher))
+	}
+
+	if ei.WebSocket {
+		builder.WriteString(":websocket")
+	}
+
+	if ei.GRPC {
+		builder.WriteString(":grpc")
+	}
+
+	for _, matcher := range ei.BodyMatchers {
+		builder.WriteString(fmt.Sprintf(":body~%s", matcher))
+	}
+
+	if ei.ScenarioName != "" {
+		builder.WriteString(fmt.Sprintf(":scenario=%s@%s", ei.ScenarioName, ei.RequiredScenarioState))
+	}
+
 	return strings.ToLower(builder.String())
 }
 
+func writeMatchers[T fmt.Stringer](builder *strings.Builder, matchers map[string]T) {
+	keys := make([]string, 0, len(matchers))
+	for key := range matchers {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		builder.WriteString(fmt.Sprintf(":%s~%s", key, matchers[key]))
+	}
+}
+
 // ValidateEndpoint valid endpoint configuration.
 func ValidateEndpoint(ep EndpointConfiguration) error {
 	if ep.EndpointID.Path == "" || ep.EndpointID.HTTPMethod == "" {
 		return fmt.Errorf("path and method are required")
 	}
 
-	if ep.ResponseBody == "" {
-		return fmt.Errorf("response body is required")
+	err := validateHTTPMethods(ep)
+	if err != nil {
+		return err
 	}
 
-	err := validateHTTPMethods(ep)
+	err = validateResponseBodies(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateMatchers(&ep.EndpointID)
+	if err != nil {
+		return err
+	}
+
+	err = validateBodyMatchers(ep.EndpointID.BodyMatchers)
+	if err != nil {
+		return err
+	}
+
+	err = validateSequence(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateScenario(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateTemplates(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateFault(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateStream(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateWebSocket(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateGRPC(&ep)
+	if err != nil {
+		return err
+	}
+
+	err = validateCallbacks(ep.Callbacks)
 	if err != nil {
 		return err
 	}
@@ -86,7 +287,7 @@ func ValidateEndpoint(ep EndpointConfiguration) error {
 }
 
 func validateHTTPMethods(ep EndpointConfiguration) error {
-	validMethods := []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"}
+	validMethods := []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", AnyHTTPMethod}
 
 	isValidMethod := slices.Contains(validMethods, ep.EndpointID.HTTPMethod)
 
@@ -99,10 +300,67 @@ func validateHTTPMethods(ep EndpointConfiguration) error {
 
 // AddEndpoint adds a new endpoint configuration to the manager.
 func (rm *ResponseManager) AddEndpoint(ec EndpointConfiguration) error {
+	_, err := rm.CreateEndpoint(ec)
+
+	return err
+}
+
+// CreateEndpoint adds a new endpoint configuration to the manager and returns its stub ID.
+func (rm *ResponseManager) CreateEndpoint(ec EndpointConfiguration) (string, error) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
-	err := ValidateEndpoint(ec)
+	err := rm.prepareEndpoint(&ec)
+	if err != nil {
+		return "", err
+	}
+
+	rm.insertEndpoint(&ec)
+
+	return ec.ID, nil
+}
+
+// CreateEndpoints adds the endpoint configurations to the manager as one change and returns their
+// stub IDs. When any configuration is invalid or conflicts with an existing stub or another
+// configuration, none of them is added.
+func (rm *ResponseManager) CreateEndpoints(ecs []EndpointConfiguration) ([]string, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	ecs = slices.Clone(ecs)
+	endpointIDs := make(map[string]bool, len(ecs))
+	stubIDs := make(map[string]bool, len(ecs))
+
+	for i := range ecs {
+		ec := &ecs[i]
+
+		err := rm.prepareEndpoint(ec)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", ec.EndpointID.HTTPMethod, ec.EndpointID.Path, err)
+		}
+
+		endpointID := GetID(&ec.EndpointID)
+		if endpointIDs[endpointID] || stubIDs[ec.ID] {
+			return nil, fmt.Errorf("%s %s: endpoint is defined more than once", ec.EndpointID.HTTPMethod, ec.EndpointID.Path)
+		}
+
+		endpointIDs[endpointID], stubIDs[ec.ID] = true, true
+	}
+
+	ids := make([]string, 0, len(ecs))
+
+	for i := range ecs {
+		rm.insertEndpoint(&ecs[i])
+		ids = append(ids, ecs[i].ID)
+	}
+
+	return ids, nil
+}
+
+// prepareEndpoint validates a new endpoint configuration against the existing endpoints and
+// generates its stub ID when it has none. Callers must hold the write lock.
+func (rm *ResponseManager) prepareEndpoint(ec *EndpointConfiguration) error {
+	err := ValidateEndpoint(*ec)
 	if err != nil {
 		return err
 	}
@@ -112,39 +370,286 @@ func (rm *ResponseManager) AddEndpoint(ec EndpointConfiguration) error {
 		return fmt.Errorf("endpoint already exists: %s", endpointID)
 	}
 
+	if ec.ID == "" {
+		ec.ID, err = newUUID()
+		if err != nil {
+			return fmt.Errorf("unable to generate stub ID: %w", err)
+		}
+	} else if _, _, exists := rm.findByStubID(ec.ID); exists {
+		return fmt.Errorf("stub ID already exists: %s", ec.ID)
+	}
+
+	return nil
+}
+
+// insertEndpoint adds an endpoint configuration that passed prepareEndpoint. Callers must hold the
+// write lock.
+func (rm *ResponseManager) insertEndpoint(ec *EndpointConfiguration) {
+	compileEndpoint(ec)
+
+	rm.endpoints[GetID(&ec.EndpointID)] = *ec
+	rm.lastAdded++
+	rm.addedOrder[ec.ID] = rm.lastAdded
+
+	rm.registerScenario(ec)
+}
+
+// compileEndpoint compiles the regular expressions of the matchers of a valid endpoint
+// configuration, so that they are not compiled again for every request.
+func compileEndpoint(ec *EndpointConfiguration) {
+	compileMatchers(&ec.EndpointID)
+
+	if ec.WebSocket != nil {
+		webSocket := *ec.WebSocket
+		webSocket.Replies = slices.Clone(webSocket.Replies)
+
+		for i := range webSocket.Replies {
+			webSocket.Replies[i].Matcher = webSocket.Replies[i].Matcher.compile()
+		}
+
+		ec.WebSocket = &webSocket
+	}
+}
+
+// GetEndpoint retrieves the configuration of the stub with the given ID.
+func (rm *ResponseManager) GetEndpoint(id string) (EndpointConfiguration, error) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	_, config, exists := rm.findByStubID(id)
+	if !exists {
+		return EndpointConfiguration{}, fmt.Errorf("stub not found: %s", id)
+	}
+
+	return config, nil
+}
+
+// UpdateEndpoint replaces the configuration of the stub with the given ID, keeping the ID. The
+// response sequence of the stub starts over.
+func (rm *ResponseManager) UpdateEndpoint(id string, ec EndpointConfiguration) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	oldEndpointID, _, exists := rm.findByStubID(id)
+	if !exists {
+		return fmt.Errorf("stub not found: %s", id)
+	}
+
+	err := ValidateEndpoint(ec)
+	if err != nil {
+		return err
+	}
+
+	endpointID := GetID(&ec.EndpointID)
+	if existing, exists := rm.endpoints[endpointID]; exists && existing.ID != id {
+		return fmt.Errorf("endpoint already exists: %s", endpointID)
+	}
+
+	// The stub keeps its position in the order in which stubs were added.
+	delete(rm.endpoints, oldEndpointID)
+	delete(rm.sequences, oldEndpointID)
+
+	ec.ID = id
+	compileEndpoint(&ec)
 	rm.endpoints[endpointID] = ec
 
+	rm.registerScenario(&ec)
+
 	return nil
 }
 
-func (rm *ResponseManager) MatchEndpoint(ei *EndpointID) (*EndpointConfiguration, error) {
+// DeleteEndpoint deletes the stub with the given ID.
+func (rm *ResponseManager) DeleteEndpoint(id string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	endpointID, _, exists := rm.findByStubID(id)
+	if !exists {
+		return fmt.Errorf("stub not found: %s", id)
+	}
+
+	rm.removeEndpoint(endpointID)
+
+	return nil
+}
+
+// findByStubID returns the endpoint ID and configuration of the stub with the given ID. Callers
+// must hold at least the read lock.
+func (rm *ResponseManager) findByStubID(id string) (string, EndpointConfiguration, bool) {
+	for endpointID, config := range rm.endpoints {
+		if config.ID == id {
+			return endpointID, config, true
+		}
+	}
+
+	return "", EndpointConfiguration{}, false
+}
+
+// MatchEndpoint finds the endpoint configuration that best matches the given request. Stub paths
+// may be templates, in which case the captured path variables are returned as part of the result.
+// The state of response sequences is left untouched.
+func (rm *ResponseManager) MatchEndpoint(ei *EndpointID) (*MatchResult, error) {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
 
-	endpointsScoring := make(map[int]*EndpointConfiguration)
-	maxScore := -1
+	return rm.matchEndpoint(ei)
+}
+
+// ServeEndpoint finds the endpoint configuration that best matches the given request and returns
+// the response to serve, moving the response sequence of the endpoint forward.
+func (rm *ResponseManager) ServeEndpoint(ei *EndpointID) (*MatchResult, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	result, err := rm.matchEndpoint(ei)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, endpoint := range rm.endpoints {
-		if endpoint.EndpointID.Path == ei.Path && endpoint.EndpointID.HTTPMethod == ei.HTTPMethod {
-			currentScore := calculateMatch(&endpoint, ei)
+	rm.advanceSequence(GetID(&result.EndpointID), &result.EndpointConfiguration)
+	rm.transitionScenario(&result.EndpointConfiguration)
 
-			if _, exists := endpointsScoring[currentScore]; exists {
-				return nil, fmt.Errorf("can't match for request: %s, to many matches", GetID(&endpoint.EndpointID))
-			}
+	return result, nil
+}
+
+// matchCandidate represents an endpoint that matches a request, with the properties that decide
+// which candidate serves the request.
+type matchCandidate struct {
+	result      *MatchResult
+	priority    int
+	score       int
+	specificity int
+	exactMethod bool
+	addedOrder  uint64
+}
 
-			endpointsScoring[currentScore] = &endpoint
+// compareCandidates orders candidates by priority, then by how many criteria matched and how
+// specific the path and method are, and finally prefers the most recently added endpoint.
+func compareCandidates(a, b *matchCandidate) int {
+	return cmp.Or(
+		cmp.Compare(a.priority, b.priority),
+		cmp.Compare(a.score, b.score),
+		cmp.Compare(a.specificity, b.specificity),
+		compareBool(a.exactMethod, b.exactMethod),
+		cmp.Compare(a.addedOrder, b.addedOrder),
+	)
+}
 
-			if currentScore > maxScore {
-				maxScore = currentScore
-			}
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// matchEndpoint selects the best candidate among the endpoints that accept the request and still
+// have a response to serve, so that an exhausted sequence gives way to other endpoints. Callers
+// must hold at least the read lock.
+func (rm *ResponseManager) matchEndpoint(ei *EndpointID) (*MatchResult, error) {
+	var best *matchCandidate
+
+	for endpointID, endpoint := range rm.endpoints {
+		if !rm.acceptsRequest(endpointID, &endpoint, ei) {
+			continue
+		}
+
+		pathParams, _ := matchEndpointPath(&endpoint.EndpointID, ei.Path)
+		response, _ := rm.currentResponse(endpointID, &endpoint)
+		exactMethod := endpoint.EndpointID.HTTPMethod == ei.HTTPMethod
+
+		candidate := &matchCandidate{
+			result:      &MatchResult{EndpointConfiguration: endpoint, PathParams: pathParams, Response: response},
+			priority:    endpoint.Priority,
+			score:       calculateMatch(&endpoint, ei) + scenarioScore(&endpoint),
+			specificity: endpointPathSpecificity(&endpoint.EndpointID),
+			exactMethod: exactMethod,
+			addedOrder:  rm.addedOrder[endpoint.ID],
+		}
+
+		if best == nil || compareCandidates(candidate, best) > 0 {
+			best = candidate
 		}
 	}
 
-	if maxScore == -1 {
+	if best == nil {
 		return nil, fmt.Errorf("no endpoints matched the given request: %s", ei.Path)
 	}
 
-	return endpointsScoring[maxScore], nil
+	return best.result, nil
+}
+
+// acceptsRequest reports whether the endpoint can serve the request, which is the case when
+// checkEndpoint finds no mismatches. Callers must hold at least the read lock.
+func (rm *ResponseManager) acceptsRequest(endpointID string, ec *EndpointConfiguration, ei *EndpointID) bool {
+	accepted := true
+
+	rm.checkEndpoint(endpointID, ec, ei, func(string, ...any) {
+		accepted = false
+	})
+
+	return accepted
+}
+
+// checkEndpoint reports every criterion of the endpoint that the request does not satisfy to
+// mismatch, with a description of the mismatch as format and arguments. It is the single
+// definition of which endpoints can serve a request, so that matching and the near misses of
+// unmatched requests agree. Exact query parameter and header values are not criteria, as they only
+// add to the score of an endpoint. Callers must hold at least the read lock.
+//
+//nolint:cyclop
+func (rm *ResponseManager) checkEndpoint(endpointID string, ec *EndpointConfiguration, ei *EndpointID, mismatch func(format string, args ...any)) {
+	stub := &ec.EndpointID
+
+	if stub.HTTPMethod != ei.HTTPMethod && stub.HTTPMethod != AnyHTTPMethod {
+		mismatch("method %s does not match %s", ei.HTTPMethod, stub.HTTPMethod)
+	}
+
+	if _, ok := matchEndpointPath(stub, ei.Path); !ok {
+		mismatch("path %s does not match %s", ei.Path, describePath(stub))
+	}
+
+	checkValueMatchers("query parameter", stub.QueryParamMatchers, ei.QueryParamsToMatch, false, mismatch)
+	checkValueMatchers("header", stub.HeaderMatchers, ei.HeadersToMatch, true, mismatch)
+	checkListMatchers("query parameter", stub.QueryParamListMatchers, requestValues(ei.QueryParams, ei.QueryParamsToMatch), false, mismatch)
+	checkListMatchers("header", stub.HeaderListMatchers, requestValues(ei.Headers, ei.HeadersToMatch), true, mismatch)
+
+	if matcher := stub.ClientCertSubjectMatcher; matcher != nil {
+		switch {
+		case ei.ClientCertSubject == "":
+			mismatch("client certificate is missing, expected subject %s", matcher)
+		case !matcher.Matches(ei.ClientCertSubject, true):
+			mismatch("client certificate subject is %s, expected %s", ei.ClientCertSubject, matcher)
+		}
+	}
+
+	if stub.WebSocket && !ei.WebSocket {
+		mismatch("request is not a WebSocket upgrade")
+	}
+
+	switch {
+	case stub.GRPC && !ei.GRPC:
+		mismatch("request is not a gRPC call")
+	case !stub.GRPC && ei.GRPC:
+		mismatch("endpoint does not stub a gRPC method")
+	}
+
+	for _, matcher := range stub.BodyMatchers {
+		if !matcher.Matches(ei.Body) {
+			mismatch("body does not match %s", matcher)
+		}
+	}
+
+	if !rm.isScenarioActive(ec) {
+		mismatch("scenario %s is in state %s instead of %s", stub.ScenarioName, rm.scenarios[stub.ScenarioName], stub.RequiredScenarioState)
+	}
+
+	if _, ok := rm.currentResponse(endpointID, ec); !ok {
+		mismatch("response sequence is exhausted")
+	}
 }
 
 func calculateMatch(ec *EndpointConfiguration, ei *EndpointID) int {
@@ -158,14 +663,27 @@ func calculateMatch(ec *EndpointConfiguration, ei *EndpointID) int {
 		}
 	}
 
-	for eiHeadersToMatchName, eiHeadersToMatchValue := range ei.HeadersToMatch {
-		for headerToMatchName, headerToMatchValue := range ec.EndpointID.HeadersToMatch {
-			if headerToMatchName == eiHeadersToMatchName && headerToMatchValue == eiHeadersToMatchValue {
-				counter++
-			}
+	for headerToMatchName, headerToMatchValue := range ec.EndpointID.HeadersToMatch {
+		if value, present := lookupHeader(ei.HeadersToMatch, headerToMatchName); present && value == headerToMatchValue {
+			counter++
 		}
 	}
 
+	counter += countMatchingValues(ec.EndpointID.QueryParamMatchers, ei.QueryParamsToMatch, false)
+	counter += countMatchingValues(ec.EndpointID.HeaderMatchers, ei.HeadersToMatch, true)
+	counter += countMatchingLists(ec.EndpointID.QueryParamListMatchers, requestValues(ei.QueryParams, ei.QueryParamsToMatch), false)
+	counter += countMatchingLists(ec.EndpointID.HeaderListMatchers, requestValues(ei.Headers, ei.HeadersToMatch), true)
+
+	if matcher := ec.EndpointID.ClientCertSubjectMatcher; matcher != nil && matcher.Matches(ei.ClientCertSubject, ei.ClientCertSubject != "") {
+		counter++
+	}
+
+	if ec.EndpointID.WebSocket && ei.WebSocket {
+		counter++
+	}
+
+	counter += scoreBodyMatchers(ec.EndpointID.BodyMatchers, ei.Body)
+
 	return counter
 }
 
@@ -178,7 +696,7 @@ func (rm *ResponseManager) GetEndpointByEndpointID(ei *EndpointID) (EndpointConf
 
 	config, exists := rm.endpoints[endpointID]
 	if !exists {
-		return EndpointConfiguration{}, fmt.Errorf("endpoint not found: %s", ei)
+		return EndpointConfiguration{}, fmt.Errorf("endpoint not found: %s", endpointID)
 	}
 
 	return config, nil
@@ -208,7 +726,7 @@ func (rm *ResponseManager) DeleteEndpointByEndpointID(ei *EndpointID) error {
 		return fmt.Errorf("endpoint not found: %s", endpointID)
 	}
 
-	delete(rm.endpoints, endpointID)
+	rm.removeEndpoint(endpointID)
 
 	return nil
 }
@@ -220,7 +738,7 @@ func (rm *ResponseManager) DeleteEndpointByPath(path string) error {
 
 	for endpointID, endpoint := range rm.endpoints {
 		if endpoint.EndpointID.Path == path {
-			delete(rm.endpoints, endpointID)
+			rm.removeEndpoint(endpointID)
 		}
 	}
 
@@ -235,7 +753,7 @@ func (rm *ResponseManager) DeleteEndpointByPathAndMethod(path, method string) er
 
 	for endpointID, endpoint := range rm.endpoints {
 		if endpoint.EndpointID.Path == path && endpoint.EndpointID.HTTPMethod == method {
-			delete(rm.endpoints, endpointID)
+			rm.removeEndpoint(endpointID)
 		}
 	}
 
@@ -250,4 +768,15 @@ func (rm *ResponseManager) DeleteAllEndpoints() {
 	for endpointID := range rm.endpoints {
 		delete(rm.endpoints, endpointID)
 	}
+
+	clear(rm.sequences)
+	clear(rm.scenarios)
+	clear(rm.addedOrder)
+}
+
+// removeEndpoint removes the endpoint and its state. Callers must hold the write lock.
+func (rm *ResponseManager) removeEndpoint(endpointID string) {
+	delete(rm.addedOrder, rm.endpoints[endpointID].ID)
+	delete(rm.endpoints, endpointID)
+	delete(rm.sequences, endpointID)
 }
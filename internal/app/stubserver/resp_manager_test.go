@@ -118,17 +118,30 @@ func TestValidateEndpoint(t *testing.T) {
 			errorMessage:  "path and method are required",
 		},
 		{
-			name: "missing response body",
+			name: "empty response body",
 			config: EndpointConfiguration{
 				EndpointID: EndpointID{
 					Path:       "/api/v1/test",
-					HTTPMethod: "GET",
+					HTTPMethod: "DELETE",
 				},
 				ResponseBody:       "",
+				ResponseStatusCode: 204,
+			},
+			expectedError: false,
+		},
+		{
+			name: "multiple response bodies",
+			config: EndpointConfiguration{
+				EndpointID: EndpointID{
+					Path:       "/api/v1/test",
+					HTTPMethod: "GET",
+				},
+				ResponseBody:       "{\"status\":\"ok\"}",
+				ResponseBodyBase64: "eyJzdGF0dXMiOiJvayJ9",
 				ResponseStatusCode: 200,
 			},
 			expectedError: true,
-			errorMessage:  "response body is required",
+			errorMessage:  "only one of response body, base64 response body and response body file can be set",
 		},
 		{
 			name: "invalid HTTP method",
@@ -218,6 +231,9 @@ func TestGetEndpointByEndpointId(t *testing.T) {
 	// Test retrieving the endpoint
 	result, err := rm.GetEndpointByEndpointID(&testEndpoint.EndpointID)
 	assert.NoError(t, err)
+	assert.NotEmpty(t, result.ID)
+
+	testEndpoint.ID = result.ID
 	assert.Equal(t, testEndpoint, result)
 
 	// Test retrieving a non-existent endpoint
@@ -762,3 +778,194 @@ func TestCalculateMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchEndpointWithPathTemplates(t *testing.T) {
+	rm := NewResponseManager()
+
+	endpoints := []EndpointConfiguration{
+		{
+			EndpointID:   EndpointID{Path: "/api/v1/users/{id}", HTTPMethod: "GET"},
+			ResponseBody: "{\"user\":\"by-id\"}",
+		},
+		{
+			EndpointID:   EndpointID{Path: "/api/v1/users/me", HTTPMethod: "GET"},
+			ResponseBody: "{\"user\":\"me\"}",
+		},
+		{
+			EndpointID:   EndpointID{Path: "/api/v1/files/**", HTTPMethod: "GET"},
+			ResponseBody: "{\"file\":true}",
+		},
+	}
+
+	for _, endpoint := range endpoints {
+		err := rm.AddEndpoint(endpoint)
+		require.NoError(t, err)
+	}
+
+	result, err := rm.MatchEndpoint(&EndpointID{Path: "/api/v1/users/42", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"user\":\"by-id\"}", result.ResponseBody)
+	assert.Equal(t, map[string]string{"id": "42"}, result.PathParams)
+
+	result, err = rm.MatchEndpoint(&EndpointID{Path: "/api/v1/users/me", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"user\":\"me\"}", result.ResponseBody)
+
+	result, err = rm.MatchEndpoint(&EndpointID{Path: "/api/v1/files/reports/2024/q1.pdf", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "{\"file\":true}", result.ResponseBody)
+
+	_, err = rm.MatchEndpoint(&EndpointID{Path: "/api/v1/users/42/orders", HTTPMethod: "GET"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no endpoints matched the given request")
+}
+
+//nolint:funlen
+func TestEndpointCRUDByStubID(t *testing.T) {
+	rm := NewResponseManager()
+
+	endpoint := EndpointConfiguration{
+		EndpointID:         EndpointID{Path: "/api/v1/orders", HTTPMethod: "GET"},
+		ResponseBody:       "[]",
+		ResponseStatusCode: 200,
+	}
+
+	id, err := rm.CreateEndpoint(endpoint)
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	_, err = rm.CreateEndpoint(EndpointConfiguration{
+		ID:           id,
+		EndpointID:   EndpointID{Path: "/api/v1/users", HTTPMethod: "GET"},
+		ResponseBody: "[]",
+	})
+	assert.ErrorContains(t, err, "stub ID already exists")
+
+	customID, err := rm.CreateEndpoint(EndpointConfiguration{
+		ID:           "users",
+		EndpointID:   EndpointID{Path: "/api/v1/users", HTTPMethod: "GET"},
+		ResponseBody: "[]",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "users", customID)
+
+	config, err := rm.GetEndpoint(id)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/orders", config.EndpointID.Path)
+
+	endpoint.EndpointID.Path = "/api/v2/orders"
+	endpoint.ResponseStatusCode = 503
+	require.NoError(t, rm.UpdateEndpoint(id, endpoint))
+
+	config, err = rm.GetEndpoint(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, config.ID)
+	assert.Equal(t, "/api/v2/orders", config.EndpointID.Path)
+	assert.Equal(t, 503, config.ResponseStatusCode)
+	assert.Len(t, rm.endpoints, 2)
+
+	result, err := rm.MatchEndpoint(&EndpointID{Path: "/api/v2/orders", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, id, result.ID)
+
+	err = rm.UpdateEndpoint(id, EndpointConfiguration{
+		EndpointID:   EndpointID{Path: "/api/v1/users", HTTPMethod: "GET"},
+		ResponseBody: "[]",
+	})
+	assert.ErrorContains(t, err, "endpoint already exists")
+
+	err = rm.UpdateEndpoint("unknown", endpoint)
+	assert.ErrorContains(t, err, "stub not found")
+
+	require.NoError(t, rm.DeleteEndpoint(id))
+	assert.Len(t, rm.endpoints, 1)
+
+	_, err = rm.GetEndpoint(id)
+	assert.ErrorContains(t, err, "stub not found")
+	assert.ErrorContains(t, rm.DeleteEndpoint(id), "stub not found")
+}
+
+//nolint:funlen
+func TestMatchEndpointTieBreakers(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoints    []EndpointConfiguration
+		request      EndpointID
+		expectedBody string
+	}{
+		{
+			name: "most recently added wins a tie",
+			endpoints: []EndpointConfiguration{
+				{EndpointID: EndpointID{Path: "/api/v1/users/{id}", HTTPMethod: "GET"}, ResponseBody: "first"},
+				{EndpointID: EndpointID{Path: "/api/v1/users/{userID}", HTTPMethod: "GET"}, ResponseBody: "second"},
+			},
+			request:      EndpointID{Path: "/api/v1/users/1", HTTPMethod: "GET"},
+			expectedBody: "second",
+		},
+		{
+			name: "priority wins over score and specificity",
+			endpoints: []EndpointConfiguration{
+				{
+					EndpointID:   EndpointID{Path: "/api/v1/users/1", HTTPMethod: "GET", HeadersToMatch: map[string]string{"X-Tenant": "a"}},
+					ResponseBody: "specific",
+				},
+				{EndpointID: EndpointID{Path: "/api/v1/users/{id}", HTTPMethod: "GET"}, ResponseBody: "priority", Priority: 1},
+			},
+			request:      EndpointID{Path: "/api/v1/users/1", HTTPMethod: "GET", HeadersToMatch: map[string]string{"X-Tenant": "a"}},
+			expectedBody: "priority",
+		},
+		{
+			name: "path specificity wins over recency",
+			endpoints: []EndpointConfiguration{
+				{EndpointID: EndpointID{Path: "/api/v1/users/me", HTTPMethod: "GET"}, ResponseBody: "literal"},
+				{EndpointID: EndpointID{Path: "/api/v1/users/{id}", HTTPMethod: "GET"}, ResponseBody: "template"},
+			},
+			request:      EndpointID{Path: "/api/v1/users/me", HTTPMethod: "GET"},
+			expectedBody: "literal",
+		},
+		{
+			name: "catch-all default with the lowest priority",
+			endpoints: []EndpointConfiguration{
+				{EndpointID: EndpointID{Path: "/**", HTTPMethod: AnyHTTPMethod}, ResponseBody: "default", Priority: -1},
+				{EndpointID: EndpointID{Path: "/api/v1/users", HTTPMethod: "POST"}, ResponseBody: "created"},
+			},
+			request:      EndpointID{Path: "/api/v1/users", HTTPMethod: "POST"},
+			expectedBody: "created",
+		},
+		{
+			name: "catch-all default serves unmatched requests",
+			endpoints: []EndpointConfiguration{
+				{EndpointID: EndpointID{Path: "/**", HTTPMethod: AnyHTTPMethod}, ResponseBody: "default", Priority: -1},
+				{EndpointID: EndpointID{Path: "/api/v1/users", HTTPMethod: "POST"}, ResponseBody: "created"},
+			},
+			request:      EndpointID{Path: "/api/v1/orders", HTTPMethod: "DELETE"},
+			expectedBody: "default",
+		},
+		{
+			name: "exact method wins over any method",
+			endpoints: []EndpointConfiguration{
+				{EndpointID: EndpointID{Path: "/api/v1/users", HTTPMethod: "GET"}, ResponseBody: "get"},
+				{EndpointID: EndpointID{Path: "/api/v1/users", HTTPMethod: AnyHTTPMethod}, ResponseBody: "any"},
+			},
+			request:      EndpointID{Path: "/api/v1/users", HTTPMethod: "GET"},
+			expectedBody: "get",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Matching must not depend on the order in which the map of endpoints is iterated.
+			for range 20 {
+				rm := NewResponseManager()
+
+				for _, endpoint := range tt.endpoints {
+					require.NoError(t, rm.AddEndpoint(endpoint))
+				}
+
+				result, err := rm.MatchEndpoint(&tt.request)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedBody, result.ResponseBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package stubserver
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ScenarioStateStarted is the state every scenario is in when it is created or reset.
+const ScenarioStateStarted = "Started"
+
+// Scenario represents a named state machine that stubs can depend on and move forward.
+type Scenario struct {
+	Name  string
+	State string
+}
+
+func validateScenario(ep *EndpointConfiguration) error {
+	if ep.EndpointID.ScenarioName == "" && (ep.EndpointID.RequiredScenarioState != "" || ep.NewScenarioState != "") {
+		return fmt.Errorf("scenario name is required when a scenario state is set")
+	}
+
+	return nil
+}
+
+// registerScenario creates the scenario of the endpoint in the started state, unless it exists
+// already. Callers must hold the write lock.
+func (rm *ResponseManager) registerScenario(ec *EndpointConfiguration) {
+	name := ec.EndpointID.ScenarioName
+	if name == "" {
+		return
+	}
+
+	if _, exists := rm.scenarios[name]; !exists {
+		rm.scenarios[name] = ScenarioStateStarted
+	}
+}
+
+// scenarioScore prefers endpoints that require a specific scenario state over endpoints that
+// match regardless of the scenario.
+func scenarioScore(ec *EndpointConfiguration) int {
+	if ec.EndpointID.RequiredScenarioState != "" {
+		return 1
+	}
+
+	return 0
+}
+
+// isScenarioActive reports whether the endpoint may be matched given the current scenario states.
+// Callers must hold at least the read lock.
+func (rm *ResponseManager) isScenarioActive(ec *EndpointConfiguration) bool {
+	if ec.EndpointID.ScenarioName == "" || ec.EndpointID.RequiredScenarioState == "" {
+		return true
+	}
+
+	return rm.scenarios[ec.EndpointID.ScenarioName] == ec.EndpointID.RequiredScenarioState
+}
+
+// transitionScenario moves the scenario of the endpoint to its new state, if any. Callers must
+// hold the write lock.
+func (rm *ResponseManager) transitionScenario(ec *EndpointConfiguration) {
+	if ec.EndpointID.ScenarioName == "" || ec.NewScenarioState == "" {
+		return
+	}
+
+	rm.scenarios[ec.EndpointID.ScenarioName] = ec.NewScenarioState
+}
+
+// GetAllScenarios retrieves all scenarios sorted by name.
+func (rm *ResponseManager) GetAllScenarios() []Scenario {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	scenarios := make([]Scenario, 0, len(rm.scenarios))
+	for name, state := range rm.scenarios {
+		scenarios = append(scenarios, Scenario{Name: name, State: state})
+	}
+
+	sort.Slice(scenarios, func(i, j int) bool {
+		return scenarios[i].Name < scenarios[j].Name
+	})
+
+	return scenarios
+}
+
+// GetScenario retrieves the scenario with the given name.
+func (rm *ResponseManager) GetScenario(name string) (Scenario, error) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	state, exists := rm.scenarios[name]
+	if !exists {
+		return Scenario{}, fmt.Errorf("scenario not found: %s", name)
+	}
+
+	return Scenario{Name: name, State: state}, nil
+}
+
+// SetScenarioState moves the scenario with the given name to the given state.
+func (rm *ResponseManager) SetScenarioState(name, state string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if _, exists := rm.scenarios[name]; !exists {
+		return fmt.Errorf("scenario not found: %s", name)
+	}
+
+	if state == "" {
+		return fmt.Errorf("scenario state is required")
+	}
+
+	rm.scenarios[name] = state
+
+	return nil
+}
+
+// ResetScenario moves the scenario with the given name back to the started state.
+func (rm *ResponseManager) ResetScenario(name string) error {
+	return rm.SetScenarioState(name, ScenarioStateStarted)
+}
+
+// ResetAllScenarios moves every scenario back to the started state.
+func (rm *ResponseManager) ResetAllScenarios() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	for name := range rm.scenarios {
+		rm.scenarios[name] = ScenarioStateStarted
+	}
+}
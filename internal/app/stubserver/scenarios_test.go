@@ -0,0 +1,110 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addCartScenario(t *testing.T, rm *ResponseManager) {
+	t.Helper()
+
+	endpoints := []EndpointConfiguration{
+		{
+			EndpointID: EndpointID{
+				Path:                  "/api/v1/cart",
+				HTTPMethod:            "GET",
+				ScenarioName:          "cart",
+				RequiredScenarioState: ScenarioStateStarted,
+			},
+			ResponseBody: "empty",
+		},
+		{
+			EndpointID: EndpointID{
+				Path:         "/api/v1/cart/items",
+				HTTPMethod:   "POST",
+				ScenarioName: "cart",
+			},
+			ResponseBody:     "added",
+			NewScenarioState: "item added",
+		},
+		{
+			EndpointID: EndpointID{
+				Path:                  "/api/v1/cart",
+				HTTPMethod:            "GET",
+				ScenarioName:          "cart",
+				RequiredScenarioState: "item added",
+			},
+			ResponseBody: "one item",
+		},
+	}
+
+	for _, endpoint := range endpoints {
+		require.NoError(t, rm.AddEndpoint(endpoint))
+	}
+}
+
+func TestServeEndpointWithScenario(t *testing.T) {
+	rm := NewResponseManager()
+	addCartScenario(t, rm)
+
+	scenario, err := rm.GetScenario("cart")
+	require.NoError(t, err)
+	assert.Equal(t, Scenario{Name: "cart", State: ScenarioStateStarted}, scenario)
+
+	result, err := rm.ServeEndpoint(&EndpointID{Path: "/api/v1/cart", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "empty", result.Response.Body)
+
+	_, err = rm.ServeEndpoint(&EndpointID{Path: "/api/v1/cart/items", HTTPMethod: "POST"})
+	require.NoError(t, err)
+
+	scenario, err = rm.GetScenario("cart")
+	require.NoError(t, err)
+	assert.Equal(t, "item added", scenario.State)
+
+	result, err = rm.ServeEndpoint(&EndpointID{Path: "/api/v1/cart", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "one item", result.Response.Body)
+
+	require.NoError(t, rm.ResetScenario("cart"))
+
+	result, err = rm.ServeEndpoint(&EndpointID{Path: "/api/v1/cart", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, "empty", result.Response.Body)
+}
+
+func TestScenarioStateManagement(t *testing.T) {
+	rm := NewResponseManager()
+	addCartScenario(t, rm)
+
+	err := rm.SetScenarioState("cart", "checked out")
+	require.NoError(t, err)
+
+	_, err = rm.ServeEndpoint(&EndpointID{Path: "/api/v1/cart", HTTPMethod: "GET"})
+	assert.Error(t, err, "no cart endpoint is active in the checked out state")
+
+	err = rm.SetScenarioState("unknown", "foo")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "scenario not found: unknown")
+
+	_, err = rm.GetScenario("unknown")
+	assert.Error(t, err)
+
+	rm.ResetAllScenarios()
+	assert.Equal(t, []Scenario{{Name: "cart", State: ScenarioStateStarted}}, rm.GetAllScenarios())
+
+	rm.DeleteAllEndpoints()
+	assert.Empty(t, rm.GetAllScenarios())
+}
+
+func TestValidateScenario(t *testing.T) {
+	err := ValidateEndpoint(EndpointConfiguration{
+		EndpointID:       EndpointID{Path: "/api/v1/test", HTTPMethod: "GET"},
+		ResponseBody:     "body",
+		NewScenarioState: "next",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "scenario name is required when a scenario state is set")
+}
@@ -0,0 +1,136 @@
+package stubserver
+
+import (
+	"fmt"
+)
+
+// SequencePolicy determines what happens once every response of a sequence has been served.
+type SequencePolicy string
+
+const (
+	// SequencePolicyRepeatLast keeps serving the last response of the sequence.
+	SequencePolicyRepeatLast SequencePolicy = "repeatLast"
+	// SequencePolicyCycle starts again with the first response of the sequence.
+	SequencePolicyCycle SequencePolicy = "cycle"
+	// SequencePolicyExhaust stops matching the endpoint, which results in a 404.
+	SequencePolicyExhaust SequencePolicy = "exhaust"
+)
+
+// Response represents a single response in a sequence of responses of a stub endpoint.
+type Response struct {
+	Headers map[string]string
+	Body    string
+	// BodyBase64 and BodyFile are alternatives to Body for binary content, holding the base64
+	// encoded body or the path of a file within the response files directory.
+	BodyBase64 string
+	BodyFile   string
+	StatusCode int
+	// StatusCodeTemplate optionally overrides StatusCode for templated endpoints.
+	StatusCodeTemplate string
+	// Times is the number of consecutive calls this response is served for. Defaults to 1.
+	Times int
+}
+
+// sequenceState tracks the position in the response sequence of an endpoint.
+type sequenceState struct {
+	index  int
+	served int
+}
+
+func validateSequence(ep *EndpointConfiguration) error {
+	switch ep.SequencePolicy {
+	case "", SequencePolicyRepeatLast, SequencePolicyCycle, SequencePolicyExhaust:
+	default:
+		return fmt.Errorf("invalid sequence policy: %s", ep.SequencePolicy)
+	}
+
+	for i, response := range ep.Responses {
+		if response.Times < 0 {
+			return fmt.Errorf("response %d: times must not be negative", i)
+		}
+	}
+
+	return nil
+}
+
+// defaultResponse returns the single response of an endpoint that does not define a sequence.
+func defaultResponse(ec *EndpointConfiguration) Response {
+	return Response{
+		Headers:            ec.ResponseHeaders,
+		Body:               ec.ResponseBody,
+		BodyBase64:         ec.ResponseBodyBase64,
+		BodyFile:           ec.ResponseBodyFile,
+		StatusCode:         ec.ResponseStatusCode,
+		StatusCodeTemplate: ec.ResponseStatusCodeTemplate,
+	}
+}
+
+// currentResponse returns the response that is served next for the endpoint, without advancing
+// the sequence. It returns false when the sequence is exhausted.
+func (rm *ResponseManager) currentResponse(endpointID string, ec *EndpointConfiguration) (Response, bool) {
+	if len(ec.Responses) == 0 {
+		return defaultResponse(ec), true
+	}
+
+	state, exists := rm.sequences[endpointID]
+	if !exists {
+		return ec.Responses[0], true
+	}
+
+	if state.index >= len(ec.Responses) {
+		return Response{}, false
+	}
+
+	return ec.Responses[state.index], true
+}
+
+// advanceSequence moves the sequence of the endpoint to the next call. Callers must hold the write lock.
+func (rm *ResponseManager) advanceSequence(endpointID string, ec *EndpointConfiguration) {
+	if len(ec.Responses) == 0 {
+		return
+	}
+
+	state, exists := rm.sequences[endpointID]
+	if !exists {
+		state = &sequenceState{}
+		rm.sequences[endpointID] = state
+	}
+
+	if state.index >= len(ec.Responses) {
+		return
+	}
+
+	state.served++
+
+	times := ec.Responses[state.index].Times
+	if times == 0 {
+		times = 1
+	}
+
+	if state.served < times {
+		return
+	}
+
+	state.index++
+	state.served = 0
+
+	if state.index < len(ec.Responses) {
+		return
+	}
+
+	switch ec.SequencePolicy {
+	case SequencePolicyCycle:
+		state.index = 0
+	case SequencePolicyExhaust:
+	default:
+		state.index = len(ec.Responses) - 1
+	}
+}
+
+// ResetSequences moves every response sequence back to its first response.
+func (rm *ResponseManager) ResetSequences() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	clear(rm.sequences)
+}
@@ -0,0 +1,160 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveStatusCodes(t *testing.T, rm *ResponseManager, ei *EndpointID, calls int) []int {
+	t.Helper()
+
+	statusCodes := make([]int, 0, calls)
+
+	for range calls {
+		result, err := rm.ServeEndpoint(ei)
+		if err != nil {
+			statusCodes = append(statusCodes, 0)
+
+			continue
+		}
+
+		statusCodes = append(statusCodes, result.Response.StatusCode)
+	}
+
+	return statusCodes
+}
+
+func TestServeEndpointWithSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   SequencePolicy
+		expected []int
+	}{
+		{
+			name:     "repeat last",
+			policy:   SequencePolicyRepeatLast,
+			expected: []int{503, 503, 500, 200, 200, 200},
+		},
+		{
+			name:     "default policy repeats last",
+			policy:   "",
+			expected: []int{503, 503, 500, 200, 200, 200},
+		},
+		{
+			name:     "cycle",
+			policy:   SequencePolicyCycle,
+			expected: []int{503, 503, 500, 200, 503, 503},
+		},
+		{
+			name:     "exhaust",
+			policy:   SequencePolicyExhaust,
+			expected: []int{503, 503, 500, 200, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := NewResponseManager()
+
+			ec := EndpointConfiguration{
+				EndpointID: EndpointID{Path: "/api/v1/retry", HTTPMethod: "GET"},
+				Responses: []Response{
+					{StatusCode: 503, Times: 2},
+					{StatusCode: 500},
+					{StatusCode: 200, Body: "ok"},
+				},
+				SequencePolicy: tt.policy,
+			}
+			require.NoError(t, rm.AddEndpoint(ec))
+
+			statusCodes := serveStatusCodes(t, rm, &EndpointID{Path: "/api/v1/retry", HTTPMethod: "GET"}, len(tt.expected))
+			assert.Equal(t, tt.expected, statusCodes)
+		})
+	}
+}
+
+func TestMatchEndpointDoesNotAdvanceSequence(t *testing.T) {
+	rm := NewResponseManager()
+
+	ec := EndpointConfiguration{
+		EndpointID: EndpointID{Path: "/api/v1/retry", HTTPMethod: "GET"},
+		Responses:  []Response{{StatusCode: 503}, {StatusCode: 200}},
+	}
+	require.NoError(t, rm.AddEndpoint(ec))
+
+	ei := EndpointID{Path: "/api/v1/retry", HTTPMethod: "GET"}
+
+	for range 3 {
+		result, err := rm.MatchEndpoint(&ei)
+		require.NoError(t, err)
+		assert.Equal(t, 503, result.Response.StatusCode)
+	}
+
+	assert.Equal(t, []int{503, 200}, serveStatusCodes(t, rm, &ei, 2))
+
+	rm.ResetSequences()
+
+	assert.Equal(t, []int{503, 200}, serveStatusCodes(t, rm, &ei, 2))
+}
+
+func TestServeEndpointFallsBackAfterExhaustedSequence(t *testing.T) {
+	rm := NewResponseManager()
+
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		EndpointID:     EndpointID{Path: "/api/v1/retry", HTTPMethod: "GET"},
+		Priority:       1,
+		Responses:      []Response{{StatusCode: 503, Times: 2}},
+		SequencePolicy: SequencePolicyExhaust,
+	}))
+	require.NoError(t, rm.AddEndpoint(EndpointConfiguration{
+		EndpointID:         EndpointID{Path: "/api/v1/**", HTTPMethod: AnyHTTPMethod},
+		ResponseStatusCode: 200,
+	}))
+
+	ei := EndpointID{Path: "/api/v1/retry", HTTPMethod: "GET"}
+
+	assert.Equal(t, []int{503, 503, 200, 200}, serveStatusCodes(t, rm, &ei, 4))
+
+	result, err := rm.MatchEndpoint(&ei)
+	require.NoError(t, err)
+	assert.Equal(t, 200, result.Response.StatusCode)
+}
+
+func TestServeEndpointWithoutSequence(t *testing.T) {
+	rm := NewResponseManager()
+
+	ec := EndpointConfiguration{
+		EndpointID:         EndpointID{Path: "/api/v1/test", HTTPMethod: "GET"},
+		ResponseHeaders:    map[string]string{"Content-Type": "application/json"},
+		ResponseBody:       "{\"status\":\"ok\"}",
+		ResponseStatusCode: 201,
+	}
+	require.NoError(t, rm.AddEndpoint(ec))
+
+	result, err := rm.ServeEndpoint(&EndpointID{Path: "/api/v1/test", HTTPMethod: "GET"})
+	require.NoError(t, err)
+	assert.Equal(t, Response{
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       "{\"status\":\"ok\"}",
+		StatusCode: 201,
+	}, result.Response)
+}
+
+func TestValidateSequence(t *testing.T) {
+	err := ValidateEndpoint(EndpointConfiguration{
+		EndpointID:     EndpointID{Path: "/api/v1/test", HTTPMethod: "GET"},
+		Responses:      []Response{{StatusCode: 200}},
+		SequencePolicy: "random",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid sequence policy: random")
+
+	err = ValidateEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{Path: "/api/v1/test", HTTPMethod: "GET"},
+		Responses:  []Response{{StatusCode: 200, Times: -1}},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "times must not be negative")
+}
@@ -2,152 +2,1365 @@ package stubserver
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/schubergphilis/mcvs-integrationtest-services/pkg/stubserver/models"
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	maxBodySizeBytes = 1024 * 10
-)
-
 // Server represents the Gin HTTP server with router and handler.
 type Server struct {
-	Router          *gin.Engine
-	responseManager *ResponseManager
+	Router     *gin.Engine
+	config     Config
+	namespaces *namespaceRegistry
+
+	// responseFiles is the directory that response body files are read from, if configured.
+	responseFiles *os.Root
+	// ca is the CA that issued the server certificate, if it was generated.
+	ca *certificateAuthority
+	// descriptors are the gRPC services that the gRPC server can stub.
+	descriptors *DescriptorRegistry
 }
 
-// NewServer creates a new instance of Server with configured routes.
+// NewServer creates a new instance of Server with configured routes and the default configuration.
 func NewServer() *Server {
+	return NewServerWithConfig(DefaultConfig())
+}
+
+// NewServerWithConfig creates a new instance of Server with configured routes and the given configuration.
+func NewServerWithConfig(config Config) *Server {
+	config = config.withDefaults()
 	router := gin.Default()
-	responseManager := NewResponseManager()
 
 	server := &Server{
-		Router:          router,
-		responseManager: responseManager,
+		Router:      router,
+		config:      config,
+		namespaces:  newNamespaceRegistry(config.JournalSize),
+		descriptors: NewDescriptorRegistry(),
+	}
+
+	router.GET(HealthEndpoint, server.health)
+	router.GET(config.AdminBasePath+CAEndpoint, server.getCA)
+	router.POST(config.AdminBasePath+ClientCertificatesEndpoint, server.issueClientCertificate)
+	router.POST(config.AdminBasePath+GRPCDescriptorsEndpoint, server.uploadDescriptorSet)
+	router.POST(config.AdminBasePath+GRPCReflectionEndpoint, server.loadDescriptorsFromReflection)
+	router.GET(config.AdminBasePath+GRPCServicesEndpoint, server.getGRPCServices)
+	router.DELETE(config.AdminBasePath+NamespacesEndpoint+"/:name", server.deleteNamespace)
+	server.registerAdminRoutes(router.Group(config.AdminBasePath))
+	server.registerAdminRoutes(router.Group(NamespacePathPrefix + "/:namespace" + config.AdminBasePath))
+
+	router.NoRoute(server.catchAll)
+
+	return server
+}
+
+// registerAdminRoutes registers the admin API, which acts on the namespace of the request.
+func (s *Server) registerAdminRoutes(group *gin.RouterGroup) {
+	group.POST(ResponsesEndpoint, s.addResponse)
+	group.GET(ResponsesEndpoint, s.getAllResponses)
+	group.DELETE(ResponsesEndpoint, s.deleteAllResponses)
+	group.GET(ExportResponsesEndpoint, s.exportResponses)
+	group.GET(ResponsesEndpoint+"/:id", s.getResponse)
+	group.PUT(ResponsesEndpoint+"/:id", s.updateResponse)
+	group.PATCH(ResponsesEndpoint+"/:id", s.patchResponse)
+	group.DELETE(ResponsesEndpoint+"/:id", s.deleteResponse)
+	group.POST(OpenAPIEndpoint, s.importOpenAPISpec)
+	group.PUT(ContractEndpoint, s.setContract)
+	group.DELETE(ContractEndpoint, s.disableContract)
+	group.GET(ViolationsEndpoint, s.getViolations)
+	group.DELETE(ViolationsEndpoint, s.deleteViolations)
+	group.DELETE(SequencesEndpoint, s.resetSequences)
+	group.GET(ScenariosEndpoint, s.getAllScenarios)
+	group.DELETE(ScenariosEndpoint, s.resetAllScenarios)
+	group.GET(ScenariosEndpoint+"/:name", s.getScenario)
+	group.PUT(ScenariosEndpoint+"/:name", s.setScenarioState)
+	group.DELETE(ScenariosEndpoint+"/:name", s.resetScenario)
+	group.GET(RequestsEndpoint, s.getRequests)
+	group.DELETE(RequestsEndpoint, s.deleteRequests)
+	group.POST(FindRequestsEndpoint, s.findRequests)
+	group.GET(UnmatchedEndpoint, s.getUnmatchedRequests)
+	group.GET(WebSocketFramesEndpoint, s.getWebSocketFrames)
+	group.DELETE(WebSocketFramesEndpoint, s.deleteWebSocketFrames)
+	group.GET(CallbacksEndpoint, s.getCallbackAttempts)
+	group.DELETE(CallbacksEndpoint, s.deleteCallbackAttempts)
+	group.GET(ProxyEndpoint, s.getProxy)
+	group.PUT(ProxyEndpoint, s.setProxy)
+	group.DELETE(ProxyEndpoint, s.disableProxy)
+}
+
+// namespace returns the namespace the request is scoped to.
+func (s *Server) namespace(c *gin.Context) *namespace {
+	return s.namespaces.get(namespaceName(c))
+}
+
+// deleteNamespace removes a namespace with its stubs, scenarios, journals and proxy. The default
+// namespace is recreated empty when it is used again.
+func (s *Server) deleteNamespace(c *gin.Context) {
+	if !s.namespaces.delete(c.Param("name")) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: fmt.Sprintf("namespace not found: %s", c.Param("name"))})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// Close stops the pending callbacks of every namespace. It is meant to be called when the server
+// shuts down.
+func (s *Server) Close() {
+	s.namespaces.close()
+}
+
+func (s *Server) health(c *gin.Context) {
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) addResponse(c *gin.Context) {
+	request, ok := bindEndpointRequest(c)
+	if !ok {
+		return
+	}
+
+	id, err := s.namespace(c).responseManager.CreateEndpoint(toEndpointConfiguration(&request))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, models.StubCreatedResponse{ID: id})
+}
+
+func (s *Server) getAllResponses(c *gin.Context) {
+	configs := s.namespace(c).responseManager.GetAllEndpointConfigurations()
+
+	responses := make([]models.EndpointResponse, 0, len(configs))
+	for _, config := range configs {
+		responses = append(responses, toEndpointResponse(&config))
+	}
+
+	c.JSON(http.StatusOK, models.EndpointListResponse{Endpoints: responses})
+}
+
+// deleteAllResponses deletes all responses, or only those with the path and, optionally, the HTTP
+// method given as query parameters.
+func (s *Server) deleteAllResponses(c *gin.Context) {
+	responseManager := s.namespace(c).responseManager
+	path, method := c.Query("path"), c.Query("method")
+
+	var err error
+
+	switch {
+	case path != "" && method != "":
+		err = responseManager.DeleteEndpointByPathAndMethod(path, method)
+	case path != "":
+		err = responseManager.DeleteEndpointByPath(path)
+	case method != "":
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Path is required when deleting by HTTP method"})
+
+		return
+	default:
+		responseManager.DeleteAllEndpoints()
+		s.namespace(c).callbackSender.CancelPending()
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) getResponse(c *gin.Context) {
+	config, err := s.namespace(c).responseManager.GetEndpoint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, toEndpointResponse(&config))
+}
+
+func (s *Server) updateResponse(c *gin.Context) {
+	_, err := s.namespace(c).responseManager.GetEndpoint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	request, ok := bindEndpointRequest(c)
+	if !ok {
+		return
+	}
+
+	s.replaceResponse(c, &request)
+}
+
+// patchResponse updates the fields of a response that are present in the request body, leaving
+// the other fields as they are.
+func (s *Server) patchResponse(c *gin.Context) {
+	config, err := s.namespace(c).responseManager.GetEndpoint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+
+		return
+	}
+
+	request, err := mergeEndpointRequest(toEndpointRequest(&config), patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+
+		return
+	}
+
+	s.replaceResponse(c, &request)
+}
+
+func (s *Server) replaceResponse(c *gin.Context, request *models.EndpointRequest) {
+	err := s.namespace(c).responseManager.UpdateEndpoint(c.Param("id"), toEndpointConfiguration(request))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) deleteResponse(c *gin.Context) {
+	err := s.namespace(c).responseManager.DeleteEndpoint(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) exportResponses(c *gin.Context) {
+	definitions := s.namespace(c).exportStubDefinitions()
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, definitions)
+	case "yaml":
+		c.YAML(http.StatusOK, definitions)
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Format must be json or yaml"})
+	}
+}
+
+// importOpenAPISpec adds a response for every operation of the OpenAPI 3 document in the request
+// body and reports the operations that were skipped.
+func (s *Server) importOpenAPISpec(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+
+		return
+	}
+
+	ids, skipped, err := s.namespace(c).importOpenAPISpec(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OpenAPIImportResponse{IDs: ids, Skipped: skipped})
+}
+
+// setContract validates the requests to the namespace against the OpenAPI 3 document in the
+// request body. With the query parameter reject=true, violating requests are answered with 400.
+func (s *Server) setContract(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+
+		return
+	}
+
+	err = s.namespace(c).contract.Configure(data, c.Query("reject") == "true")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) disableContract(c *gin.Context) {
+	s.namespace(c).contract.Disable()
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) getViolations(c *gin.Context) {
+	violations := s.namespace(c).contract.Violations()
+
+	response := models.ViolationListResponse{Violations: make([]models.Violation, 0, len(violations))}
+	for _, violation := range violations {
+		response.Violations = append(response.Violations, toModelViolation(&violation))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) deleteViolations(c *gin.Context) {
+	s.namespace(c).contract.ClearViolations()
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) resetSequences(c *gin.Context) {
+	s.namespace(c).responseManager.ResetSequences()
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) getAllScenarios(c *gin.Context) {
+	scenarios := s.namespace(c).responseManager.GetAllScenarios()
+
+	response := models.ScenarioListResponse{Scenarios: make([]models.Scenario, 0, len(scenarios))}
+	for _, scenario := range scenarios {
+		response.Scenarios = append(response.Scenarios, models.Scenario{Name: scenario.Name, State: scenario.State})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getScenario(c *gin.Context) {
+	scenario, err := s.namespace(c).responseManager.GetScenario(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Scenario{Name: scenario.Name, State: scenario.State})
+}
+
+func (s *Server) setScenarioState(c *gin.Context) {
+	var request models.ScenarioStateRequest
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil || request.State == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Scenario state is required"})
+
+		return
+	}
+
+	err = s.namespace(c).responseManager.SetScenarioState(c.Param("name"), request.State)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) resetScenario(c *gin.Context) {
+	err := s.namespace(c).responseManager.ResetScenario(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) resetAllScenarios(c *gin.Context) {
+	s.namespace(c).responseManager.ResetAllScenarios()
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) getRequests(c *gin.Context) {
+	filter, err := parseJournalFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	entries := s.namespace(c).journal.Find(filter)
+
+	response := models.JournalListResponse{Requests: make([]models.JournalEntry, 0, len(entries))}
+	for _, entry := range entries {
+		response.Requests = append(response.Requests, toModelJournalEntry(&entry))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) findRequests(c *gin.Context) {
+	var request models.RequestMatcher
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
+
+		return
+	}
+
+	endpointID := toEndpointID(&request)
+
+	err = validateMatchers(&endpointID)
+	if err == nil {
+		err = validateBodyMatchers(endpointID.BodyMatchers)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	entries := s.namespace(c).journal.FindMatching(&endpointID)
+
+	response := models.JournalListResponse{Requests: make([]models.JournalEntry, 0, len(entries))}
+	for _, entry := range entries {
+		response.Requests = append(response.Requests, toModelJournalEntry(&entry))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getUnmatchedRequests(c *gin.Context) {
+	entries := s.namespace(c).journal.Unmatched()
+
+	response := models.UnmatchedRequestListResponse{Requests: make([]models.UnmatchedRequest, 0, len(entries))}
+	for _, entry := range entries {
+		response.Requests = append(response.Requests, models.UnmatchedRequest{
+			Request:    toModelJournalEntry(&entry),
+			NearMisses: toModelNearMisses(entry.NearMisses),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) deleteRequests(c *gin.Context) {
+	s.namespace(c).journal.Clear()
+	c.Status(http.StatusOK)
+}
+
+// getWebSocketFrames returns the frames received by WebSocket endpoints, optionally only those of
+// the stub given as query parameter stubId.
+func (s *Server) getWebSocketFrames(c *gin.Context) {
+	frames := s.namespace(c).webSocketFrames.Find(c.Query("stubId"))
+
+	response := models.WebSocketFrameListResponse{Frames: make([]models.WebSocketFrame, 0, len(frames))}
+	for _, frame := range frames {
+		response.Frames = append(response.Frames, toModelWebSocketFrame(&frame))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) deleteWebSocketFrames(c *gin.Context) {
+	s.namespace(c).webSocketFrames.Clear()
+	c.Status(http.StatusOK)
+}
+
+// getCallbackAttempts returns the attempts to send callbacks, optionally only those of the stub
+// given as query parameter stubId.
+func (s *Server) getCallbackAttempts(c *gin.Context) {
+	attempts := s.namespace(c).callbacks.Find(c.Query("stubId"))
+
+	response := models.CallbackAttemptListResponse{Attempts: make([]models.CallbackAttempt, 0, len(attempts))}
+	for _, attempt := range attempts {
+		response.Attempts = append(response.Attempts, toModelCallbackAttempt(&attempt))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) deleteCallbackAttempts(c *gin.Context) {
+	s.namespace(c).callbacks.Clear()
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) getProxy(c *gin.Context) {
+	config := s.namespace(c).proxy.Configuration()
+	c.JSON(http.StatusOK, models.ProxyConfiguration{UpstreamURL: config.UpstreamURL, Record: config.Record})
+}
+
+func (s *Server) setProxy(c *gin.Context) {
+	var request models.ProxyConfiguration
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil || request.UpstreamURL == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Upstream URL is required"})
+
+		return
+	}
+
+	err = s.namespace(c).proxy.Configure(ProxyConfiguration{UpstreamURL: request.UpstreamURL, Record: request.Record})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) disableProxy(c *gin.Context) {
+	s.namespace(c).proxy.Disable()
+	c.Status(http.StatusOK)
+}
+
+func (s *Server) catchAll(c *gin.Context) {
+	ns := s.catchAllNamespace(c)
+	body := readRequestBody(c)
+
+	logRequestContext(c, body, s.config.MaxLoggedBodyBytes)
+
+	endpointID := EndpointID{
+		Path:               c.Request.URL.Path,
+		HTTPMethod:         c.Request.Method,
+		QueryParamsToMatch: flattenQueryParams(c),
+		HeadersToMatch:     flattenHeaders(c),
+		QueryParams:        c.Request.URL.Query(),
+		Headers:            c.Request.Header,
+		ClientCertSubject:  clientCertSubject(c.Request),
+		WebSocket:          isWebSocketUpgrade(c.Request.Header),
+		Body:               body,
+	}
+
+	journalEntry := newJournalEntry(c, body)
+
+	violation, reject := ns.contract.Validate(c.Request, body)
+	if violation != nil {
+		log.WithFields(log.Fields{"urlPath": c.Request.URL.Path, "errors": violation.Errors}).Warn("request violates the OpenAPI contract")
+
+		journalEntry.ContractErrors = violation.Errors
+
+		if reject {
+			journalEntry.NearMisses = ns.responseManager.NearMisses(&endpointID)
+			ns.journal.Record(journalEntry)
+			c.JSON(http.StatusBadRequest, models.ContractViolationResponse{
+				Error:     "request violates the OpenAPI contract",
+				Violation: toModelViolation(violation),
+			})
+
+			return
+		}
+	}
+
+	result, err := ns.responseManager.ServeEndpoint(&endpointID)
+	if err != nil {
+		journalEntry.NearMisses = ns.responseManager.NearMisses(&endpointID)
+		ns.journal.Record(journalEntry)
+
+		if ns.proxy.Forward(c.Writer, c.Request, body) {
+			return
+		}
+
+		log.WithError(err).WithFields(log.Fields{"urlPath": c.Request.URL.Path, "nearMisses": len(journalEntry.NearMisses)}).Error("endpoint not found")
+		c.JSON(http.StatusNotFound, models.UnmatchedResponse{
+			Error:      err.Error(),
+			NearMisses: toModelNearMisses(journalEntry.NearMisses),
+		})
+
+		return
+	}
+
+	journalEntry.MatchedEndpointID = result.ID
+	ns.journal.Record(journalEntry)
+
+	response := result.Response
+
+	if result.ResponseTemplating {
+		response, err = renderResponse(response, NewTemplateData(&endpointID, result.PathParams))
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{"urlPath": c.Request.URL.Path}).Error("unable to render response template")
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+
+			return
+		}
+	}
+
+	err = s.resolveResponseBody(&response)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{"urlPath": c.Request.URL.Path}).Error("unable to load response body")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	if result.Fault != nil {
+		sleep(c.Request.Context(), result.Fault.Delay())
+
+		if result.Fault.ShouldFail() {
+			err = writeFault(c.Writer, result.Fault.Type, &response)
+			if err != nil {
+				log.WithError(err).WithFields(log.Fields{"urlPath": c.Request.URL.Path}).Error("unable to inject fault")
+			}
+
+			c.Abort()
+
+			return
+		}
+	}
+
+	fireCallbacks := func() {
+		ns.callbackSender.Fire(result.ID, result.Callbacks, NewTemplateData(&endpointID, result.PathParams))
+	}
+
+	if result.WebSocket != nil {
+		serveWebSocket(c, ns.webSocketFrames, result.ID, result.WebSocket, response.Headers, fireCallbacks)
+
+		return
+	}
+
+	// 1. Set response headers
+	for key, value := range response.Headers {
+		c.Header(key, value)
+	}
+
+	// 2. Set status code (default to 200 if not set)
+	statusCode := response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	switch {
+	case result.Stream != nil:
+		writeStream(c, statusCode, result.Stream, fireCallbacks)
+
+		return
+	case response.Body == "":
+		c.Status(statusCode)
+	case response.isBinary():
+		c.Data(statusCode, c.Writer.Header().Get(contentTypeHeader), []byte(response.Body))
+	default:
+		c.String(statusCode, response.Body)
 	}
 
-	router.GET(HealthEndpoint, server.health)
-	router.POST(BaseURLPath+ResponsesEndpoint, server.addResponse)
-	router.GET(BaseURLPath+ResponsesEndpoint, server.getAllResponses)
-	router.DELETE(BaseURLPath+ResponsesEndpoint, server.deleteAllResponses)
+	// Callbacks without a delay must not reach their receiver before the response left.
+	c.Writer.Flush()
+	fireCallbacks()
+}
 
-	router.NoRoute(server.catchAll)
+// catchAllNamespace returns the namespace of a stubbed request. A namespace prefix in the path takes
+// precedence over the namespace header and is stripped, so stub paths are relative to the namespace.
+func (s *Server) catchAllNamespace(c *gin.Context) *namespace {
+	name, path, found := stripNamespacePrefix(c.Request.URL.Path)
+	if !found {
+		return s.namespace(c)
+	}
 
-	return server
-}
+	c.Request.URL.Path = path
+	c.Request.URL.RawPath = ""
 
-func (s *Server) health(c *gin.Context) {
-	c.Status(http.StatusOK)
+	return s.namespaces.get(name)
 }
 
-func (s *Server) addResponse(c *gin.Context) {
+// bindEndpointRequest binds and checks the endpoint request in the request body. It writes an error
+// response and returns false when the request is invalid.
+func bindEndpointRequest(c *gin.Context) (models.EndpointRequest, bool) {
 	var request models.EndpointRequest
 
 	err := c.ShouldBindJSON(&request)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request body"})
 
-		return
+		return request, false
 	}
 
 	if request.Path == "" || request.HTTPMethod == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Path and HTTP method are required"})
 
-		return
+		return request, false
 	}
 
-	if request.ResponseBody == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Response body is required"})
+	return request, true
+}
+
+// mergeEndpointRequest replaces the top-level fields of the endpoint request with the fields in
+// the JSON patch. A null value resets a field.
+func mergeEndpointRequest(request models.EndpointRequest, patch []byte) (models.EndpointRequest, error) {
+	var patchFields map[string]json.RawMessage
 
-		return
+	err := json.Unmarshal(patch, &patchFields)
+	if err != nil {
+		return models.EndpointRequest{}, fmt.Errorf("invalid patch: %w", err)
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return models.EndpointRequest{}, fmt.Errorf("unable to marshal endpoint request: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return models.EndpointRequest{}, fmt.Errorf("unable to unmarshal endpoint request: %w", err)
+	}
+
+	maps.Copy(fields, patchFields)
+
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return models.EndpointRequest{}, fmt.Errorf("unable to marshal endpoint request: %w", err)
+	}
+
+	var merged models.EndpointRequest
+
+	err = json.Unmarshal(data, &merged)
+	if err != nil {
+		return models.EndpointRequest{}, fmt.Errorf("invalid patch: %w", err)
 	}
 
-	endpointConfig := EndpointConfiguration{
+	return merged, nil
+}
+
+func toEndpointConfiguration(request *models.EndpointRequest) EndpointConfiguration {
+	return EndpointConfiguration{
+		ID: request.ID,
 		EndpointID: EndpointID{
-			Path:               request.Path,
-			HTTPMethod:         request.HTTPMethod,
-			QueryParamsToMatch: request.QueryParamsToMatch,
-			HeadersToMatch:     request.HeadersToMatch,
+			Path:                     request.Path,
+			HTTPMethod:               request.HTTPMethod,
+			QueryParamsToMatch:       request.QueryParamsToMatch,
+			HeadersToMatch:           request.HeadersToMatch,
+			PathMatchType:            MatchType(request.PathMatchType),
+			QueryParamMatchers:       toValueMatchers(request.QueryParamMatchers),
+			HeaderMatchers:           toValueMatchers(request.HeaderMatchers),
+			QueryParamListMatchers:   toListMatchers(request.QueryParamListMatchers),
+			HeaderListMatchers:       toListMatchers(request.HeaderListMatchers),
+			ClientCertSubjectMatcher: toValueMatcher(request.ClientCertSubjectMatcher),
+			WebSocket:                request.WebSocket != nil,
+			GRPC:                     request.GRPC != nil,
+			BodyMatchers:             toBodyMatchers(request.BodyMatchers),
+			ScenarioName:             request.ScenarioName,
+			RequiredScenarioState:    request.RequiredScenarioState,
 		},
-		ResponseHeaders:    request.ResponseHeaders,
-		ResponseBody:       request.ResponseBody,
-		ResponseStatusCode: request.ResponseStatusCode,
+		ResponseHeaders:            request.ResponseHeaders,
+		ResponseBody:               request.ResponseBody,
+		ResponseBodyBase64:         request.ResponseBodyBase64,
+		ResponseBodyFile:           request.ResponseBodyFile,
+		ResponseStatusCode:         request.ResponseStatusCode,
+		ResponseTemplating:         request.ResponseTemplating,
+		ResponseStatusCodeTemplate: request.ResponseStatusCodeTemplate,
+		Responses:                  toResponses(request.Responses),
+		SequencePolicy:             SequencePolicy(request.SequencePolicy),
+		NewScenarioState:           request.NewScenarioState,
+		Fault:                      toFault(request.Fault),
+		Stream:                     toStream(request.Stream),
+		WebSocket:                  toWebSocket(request.WebSocket),
+		GRPC:                       toGRPC(request.GRPC),
+		Callbacks:                  toCallbacks(request.Callbacks),
+		Priority:                   request.Priority,
 	}
+}
 
-	err = s.responseManager.AddEndpoint(endpointConfig)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+func toEndpointID(matcher *models.RequestMatcher) EndpointID {
+	return EndpointID{
+		Path:                     matcher.Path,
+		HTTPMethod:               matcher.HTTPMethod,
+		QueryParamsToMatch:       matcher.QueryParamsToMatch,
+		HeadersToMatch:           matcher.HeadersToMatch,
+		PathMatchType:            MatchType(matcher.PathMatchType),
+		QueryParamMatchers:       toValueMatchers(matcher.QueryParamMatchers),
+		HeaderMatchers:           toValueMatchers(matcher.HeaderMatchers),
+		QueryParamListMatchers:   toListMatchers(matcher.QueryParamListMatchers),
+		HeaderListMatchers:       toListMatchers(matcher.HeaderListMatchers),
+		ClientCertSubjectMatcher: toValueMatcher(matcher.ClientCertSubjectMatcher),
+		BodyMatchers:             toBodyMatchers(matcher.BodyMatchers),
+	}
+}
 
-		return
+func toEndpointRequest(config *EndpointConfiguration) models.EndpointRequest {
+	return models.EndpointRequest{
+		ID:                         config.ID,
+		Path:                       config.EndpointID.Path,
+		HTTPMethod:                 config.EndpointID.HTTPMethod,
+		QueryParamsToMatch:         config.EndpointID.QueryParamsToMatch,
+		HeadersToMatch:             config.EndpointID.HeadersToMatch,
+		PathMatchType:              string(config.EndpointID.PathMatchType),
+		QueryParamMatchers:         toModelValueMatchers(config.EndpointID.QueryParamMatchers),
+		HeaderMatchers:             toModelValueMatchers(config.EndpointID.HeaderMatchers),
+		QueryParamListMatchers:     toModelListMatchers(config.EndpointID.QueryParamListMatchers),
+		HeaderListMatchers:         toModelListMatchers(config.EndpointID.HeaderListMatchers),
+		ClientCertSubjectMatcher:   toModelValueMatcher(config.EndpointID.ClientCertSubjectMatcher),
+		BodyMatchers:               toModelBodyMatchers(config.EndpointID.BodyMatchers),
+		ResponseHeaders:            config.ResponseHeaders,
+		ResponseBody:               config.ResponseBody,
+		ResponseBodyBase64:         config.ResponseBodyBase64,
+		ResponseBodyFile:           config.ResponseBodyFile,
+		ResponseStatusCode:         config.ResponseStatusCode,
+		ResponseTemplating:         config.ResponseTemplating,
+		ResponseStatusCodeTemplate: config.ResponseStatusCodeTemplate,
+		Responses:                  toSequenceResponses(config.Responses),
+		SequencePolicy:             string(config.SequencePolicy),
+		ScenarioName:               config.EndpointID.ScenarioName,
+		RequiredScenarioState:      config.EndpointID.RequiredScenarioState,
+		NewScenarioState:           config.NewScenarioState,
+		Fault:                      toModelFault(config.Fault),
+		Stream:                     toModelStream(config.Stream),
+		WebSocket:                  toModelWebSocket(config.WebSocket),
+		GRPC:                       toModelGRPC(config.GRPC),
+		Callbacks:                  toModelCallbacks(config.Callbacks),
+		Priority:                   config.Priority,
 	}
+}
 
-	c.Status(http.StatusOK)
+func toEndpointResponse(config *EndpointConfiguration) models.EndpointResponse {
+	return models.EndpointResponse{
+		ID:                         config.ID,
+		Path:                       config.EndpointID.Path,
+		HTTPMethod:                 config.EndpointID.HTTPMethod,
+		QueryParamsToMatch:         config.EndpointID.QueryParamsToMatch,
+		HeadersToMatch:             config.EndpointID.HeadersToMatch,
+		PathMatchType:              string(config.EndpointID.PathMatchType),
+		QueryParamMatchers:         toModelValueMatchers(config.EndpointID.QueryParamMatchers),
+		HeaderMatchers:             toModelValueMatchers(config.EndpointID.HeaderMatchers),
+		QueryParamListMatchers:     toModelListMatchers(config.EndpointID.QueryParamListMatchers),
+		HeaderListMatchers:         toModelListMatchers(config.EndpointID.HeaderListMatchers),
+		ClientCertSubjectMatcher:   toModelValueMatcher(config.EndpointID.ClientCertSubjectMatcher),
+		BodyMatchers:               toModelBodyMatchers(config.EndpointID.BodyMatchers),
+		ResponseHeaders:            config.ResponseHeaders,
+		ResponseBody:               config.ResponseBody,
+		ResponseBodyBase64:         config.ResponseBodyBase64,
+		ResponseBodyFile:           config.ResponseBodyFile,
+		ResponseStatusCode:         config.ResponseStatusCode,
+		ResponseTemplating:         config.ResponseTemplating,
+		ResponseStatusCodeTemplate: config.ResponseStatusCodeTemplate,
+		Responses:                  toSequenceResponses(config.Responses),
+		SequencePolicy:             string(config.SequencePolicy),
+		ScenarioName:               config.EndpointID.ScenarioName,
+		RequiredScenarioState:      config.EndpointID.RequiredScenarioState,
+		NewScenarioState:           config.NewScenarioState,
+		Fault:                      toModelFault(config.Fault),
+		Stream:                     toModelStream(config.Stream),
+		WebSocket:                  toModelWebSocket(config.WebSocket),
+		GRPC:                       toModelGRPC(config.GRPC),
+		Callbacks:                  toModelCallbacks(config.Callbacks),
+		Priority:                   config.Priority,
+	}
 }
 
-func (s *Server) getAllResponses(c *gin.Context) {
-	configs := s.responseManager.GetAllEndpointConfigurations()
+func toResponses(responses []models.SequenceResponse) []Response {
+	if responses == nil {
+		return nil
+	}
 
-	responses := make([]models.EndpointResponse, 0, len(configs))
-	for _, config := range configs {
-		responses = append(responses, models.EndpointResponse{
-			Path:               config.EndpointID.Path,
-			HTTPMethod:         config.EndpointID.HTTPMethod,
-			QueryParamsToMatch: config.EndpointID.QueryParamsToMatch,
-			HeadersToMatch:     config.EndpointID.HeadersToMatch,
-			ResponseHeaders:    config.ResponseHeaders,
-			ResponseBody:       config.ResponseBody,
-			ResponseStatusCode: config.ResponseStatusCode,
+	result := make([]Response, 0, len(responses))
+	for _, response := range responses {
+		result = append(result, Response{
+			Headers:            response.ResponseHeaders,
+			Body:               response.ResponseBody,
+			BodyBase64:         response.ResponseBodyBase64,
+			BodyFile:           response.ResponseBodyFile,
+			StatusCode:         response.ResponseStatusCode,
+			StatusCodeTemplate: response.ResponseStatusCodeTemplate,
+			Times:              response.Times,
 		})
 	}
 
-	c.JSON(http.StatusOK, models.EndpointListResponse{Endpoints: responses})
+	return result
 }
 
-func (s *Server) deleteAllResponses(c *gin.Context) {
-	s.responseManager.DeleteAllEndpoints()
-	c.Status(http.StatusOK)
+func toSequenceResponses(responses []Response) []models.SequenceResponse {
+	if responses == nil {
+		return nil
+	}
+
+	result := make([]models.SequenceResponse, 0, len(responses))
+	for _, response := range responses {
+		result = append(result, models.SequenceResponse{
+			ResponseHeaders:            response.Headers,
+			ResponseBody:               response.Body,
+			ResponseBodyBase64:         response.BodyBase64,
+			ResponseBodyFile:           response.BodyFile,
+			ResponseStatusCode:         response.StatusCode,
+			ResponseStatusCodeTemplate: response.StatusCodeTemplate,
+			Times:                      response.Times,
+		})
+	}
+
+	return result
 }
 
-func (s *Server) catchAll(c *gin.Context) {
-	logRequestContext(c)
+func toFault(fault *models.Fault) *Fault {
+	if fault == nil {
+		return nil
+	}
 
-	endpointID := EndpointID{
-		Path:               c.Request.URL.Path,
-		HTTPMethod:         c.Request.Method,
-		QueryParamsToMatch: flattenQueryParams(c),
-		HeadersToMatch:     flattenHeaders(c),
+	return &Fault{
+		FixedDelay:     time.Duration(fault.FixedDelayMilliseconds) * time.Millisecond,
+		RandomDelayMin: time.Duration(fault.RandomDelayMinMilliseconds) * time.Millisecond,
+		RandomDelayMax: time.Duration(fault.RandomDelayMaxMilliseconds) * time.Millisecond,
+		Type:           FaultType(fault.Type),
+		Probability:    fault.Probability,
 	}
+}
 
-	config, err := s.responseManager.MatchEndpoint(&endpointID)
-	if err != nil {
-		log.WithFields(log.Fields{"urlPath": c.Request.URL.Path}).Error("endpoint not found")
-		c.Status(http.StatusNotFound)
+func toModelFault(fault *Fault) *models.Fault {
+	if fault == nil {
+		return nil
+	}
 
-		return
+	return &models.Fault{
+		FixedDelayMilliseconds:     int(fault.FixedDelay.Milliseconds()),
+		RandomDelayMinMilliseconds: int(fault.RandomDelayMin.Milliseconds()),
+		RandomDelayMaxMilliseconds: int(fault.RandomDelayMax.Milliseconds()),
+		Type:                       string(fault.Type),
+		Probability:                fault.Probability,
 	}
+}
 
-	// 1. Set response headers
-	for key, value := range config.ResponseHeaders {
-		c.Header(key, value)
+func toStream(stream *models.Stream) *Stream {
+	if stream == nil {
+		return nil
 	}
 
-	// 2. Set status code (default to 200 if not set)
-	statusCode := config.ResponseStatusCode
-	if statusCode == 0 {
-		statusCode = http.StatusOK
+	chunks := make([]StreamChunk, 0, len(stream.Chunks))
+	for _, chunk := range stream.Chunks {
+		chunks = append(chunks, StreamChunk{
+			Delay: time.Duration(chunk.DelayMilliseconds) * time.Millisecond,
+			Data:  chunk.Data,
+			ID:    chunk.ID,
+			Event: chunk.Event,
+		})
 	}
 
-	if config.ResponseBody == "" {
-		c.Status(statusCode)
-	} else {
-		c.String(statusCode, config.ResponseBody)
+	return &Stream{Mode: StreamMode(stream.Mode), Chunks: chunks, HoldOpen: stream.HoldOpen}
+}
+
+func toModelStream(stream *Stream) *models.Stream {
+	if stream == nil {
+		return nil
+	}
+
+	chunks := make([]models.StreamChunk, 0, len(stream.Chunks))
+	for _, chunk := range stream.Chunks {
+		chunks = append(chunks, models.StreamChunk{
+			DelayMilliseconds: int(chunk.Delay.Milliseconds()),
+			Data:              chunk.Data,
+			ID:                chunk.ID,
+			Event:             chunk.Event,
+		})
+	}
+
+	return &models.Stream{Mode: string(stream.Mode), Chunks: chunks, HoldOpen: stream.HoldOpen}
+}
+
+func toWebSocket(script *models.WebSocket) *WebSocket {
+	if script == nil {
+		return nil
+	}
+
+	replies := make([]WebSocketReply, 0, len(script.Replies))
+	for _, reply := range script.Replies {
+		replies = append(replies, WebSocketReply{
+			Matcher:  ValueMatcher{MatchType: MatchType(reply.Matcher.MatchType), Value: reply.Matcher.Value},
+			Messages: toWebSocketMessages(reply.Messages),
+		})
+	}
+
+	result := &WebSocket{OnConnect: toWebSocketMessages(script.OnConnect), Replies: replies}
+
+	if script.Close != nil {
+		result.Close = &WebSocketClose{
+			Delay:  time.Duration(script.Close.DelayMilliseconds) * time.Millisecond,
+			Code:   script.Close.Code,
+			Reason: script.Close.Reason,
+		}
+	}
+
+	return result
+}
+
+func toWebSocketMessages(messages []models.WebSocketMessage) []WebSocketMessage {
+	result := make([]WebSocketMessage, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, WebSocketMessage{
+			Delay: time.Duration(message.DelayMilliseconds) * time.Millisecond,
+			Data:  message.Data,
+		})
+	}
+
+	return result
+}
+
+func toModelWebSocket(script *WebSocket) *models.WebSocket {
+	if script == nil {
+		return nil
+	}
+
+	replies := make([]models.WebSocketReply, 0, len(script.Replies))
+	for _, reply := range script.Replies {
+		replies = append(replies, models.WebSocketReply{
+			Matcher:  models.ValueMatcher{MatchType: string(reply.Matcher.MatchType), Value: reply.Matcher.Value},
+			Messages: toModelWebSocketMessages(reply.Messages),
+		})
+	}
+
+	result := &models.WebSocket{OnConnect: toModelWebSocketMessages(script.OnConnect), Replies: replies}
+
+	if script.Close != nil {
+		result.Close = &models.WebSocketClose{
+			DelayMilliseconds: int(script.Close.Delay.Milliseconds()),
+			Code:              script.Close.Code,
+			Reason:            script.Close.Reason,
+		}
+	}
+
+	return result
+}
+
+func toModelWebSocketMessages(messages []WebSocketMessage) []models.WebSocketMessage {
+	result := make([]models.WebSocketMessage, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, models.WebSocketMessage{
+			DelayMilliseconds: int(message.Delay.Milliseconds()),
+			Data:              message.Data,
+		})
+	}
+
+	return result
+}
+
+func toGRPC(grpcResponse *models.GRPC) *GRPC {
+	if grpcResponse == nil {
+		return nil
+	}
+
+	messages := make([]GRPCMessage, 0, len(grpcResponse.Messages))
+	for _, message := range grpcResponse.Messages {
+		messages = append(messages, GRPCMessage{
+			Delay: time.Duration(message.DelayMilliseconds) * time.Millisecond,
+			Data:  message.Data,
+		})
+	}
+
+	return &GRPC{
+		Code:     grpcResponse.Code,
+		Message:  grpcResponse.Message,
+		Trailers: grpcResponse.Trailers,
+		Messages: messages,
+	}
+}
+
+func toModelGRPC(grpcResponse *GRPC) *models.GRPC {
+	if grpcResponse == nil {
+		return nil
+	}
+
+	messages := make([]models.GRPCMessage, 0, len(grpcResponse.Messages))
+	for _, message := range grpcResponse.Messages {
+		messages = append(messages, models.GRPCMessage{
+			DelayMilliseconds: int(message.Delay.Milliseconds()),
+			Data:              message.Data,
+		})
+	}
+
+	return &models.GRPC{
+		Code:     grpcResponse.Code,
+		Message:  grpcResponse.Message,
+		Trailers: grpcResponse.Trailers,
+		Messages: messages,
+	}
+}
+
+func toCallbacks(callbacks []models.Callback) []Callback {
+	if callbacks == nil {
+		return nil
+	}
+
+	result := make([]Callback, 0, len(callbacks))
+	for _, callback := range callbacks {
+		result = append(result, Callback{
+			URL:        callback.URL,
+			Method:     callback.Method,
+			Headers:    callback.Headers,
+			Body:       callback.Body,
+			Delay:      time.Duration(callback.DelayMilliseconds) * time.Millisecond,
+			Retries:    callback.Retries,
+			RetryDelay: time.Duration(callback.RetryDelayMilliseconds) * time.Millisecond,
+		})
+	}
+
+	return result
+}
+
+func toModelCallbacks(callbacks []Callback) []models.Callback {
+	if callbacks == nil {
+		return nil
+	}
+
+	result := make([]models.Callback, 0, len(callbacks))
+	for _, callback := range callbacks {
+		result = append(result, models.Callback{
+			URL:                    callback.URL,
+			Method:                 callback.Method,
+			Headers:                callback.Headers,
+			Body:                   callback.Body,
+			DelayMilliseconds:      int(callback.Delay.Milliseconds()),
+			Retries:                callback.Retries,
+			RetryDelayMilliseconds: int(callback.RetryDelay.Milliseconds()),
+		})
+	}
+
+	return result
+}
+
+func toModelCallbackAttempt(attempt *CallbackAttempt) models.CallbackAttempt {
+	return models.CallbackAttempt{
+		ID:                   attempt.ID,
+		Timestamp:            attempt.Timestamp,
+		StubID:               attempt.StubID,
+		Callback:             attempt.Callback,
+		Attempt:              attempt.Attempt,
+		Method:               attempt.Method,
+		URL:                  attempt.URL,
+		Body:                 attempt.Body,
+		StatusCode:           attempt.StatusCode,
+		Error:                attempt.Error,
+		DurationMilliseconds: int(attempt.Duration.Milliseconds()),
+	}
+}
+
+func toModelWebSocketFrame(frame *WebSocketFrame) models.WebSocketFrame {
+	return models.WebSocketFrame{
+		ID:         frame.ID,
+		Timestamp:  frame.Timestamp,
+		StubID:     frame.StubID,
+		Connection: frame.Connection,
+		Binary:     frame.Binary,
+		Data:       encodeFrameData(frame),
+	}
+}
+
+func toValueMatchers(matchers map[string]models.ValueMatcher) map[string]ValueMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	result := make(map[string]ValueMatcher, len(matchers))
+	for name, matcher := range matchers {
+		result[name] = ValueMatcher{MatchType: MatchType(matcher.MatchType), Value: matcher.Value}
+	}
+
+	return result
+}
+
+func toModelValueMatchers(matchers map[string]ValueMatcher) map[string]models.ValueMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	result := make(map[string]models.ValueMatcher, len(matchers))
+	for name, matcher := range matchers {
+		result[name] = models.ValueMatcher{MatchType: string(matcher.MatchType), Value: matcher.Value}
+	}
+
+	return result
+}
+
+func toValueMatcher(matcher *models.ValueMatcher) *ValueMatcher {
+	if matcher == nil {
+		return nil
+	}
+
+	return &ValueMatcher{MatchType: MatchType(matcher.MatchType), Value: matcher.Value}
+}
+
+func toModelValueMatcher(matcher *ValueMatcher) *models.ValueMatcher {
+	if matcher == nil {
+		return nil
+	}
+
+	return &models.ValueMatcher{MatchType: string(matcher.MatchType), Value: matcher.Value}
+}
+
+func toListMatchers(matchers map[string]models.ListMatcher) map[string]ListMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	result := make(map[string]ListMatcher, len(matchers))
+	for name, matcher := range matchers {
+		result[name] = ListMatcher{MatchType: ListMatchType(matcher.MatchType), Values: matcher.Values}
+	}
+
+	return result
+}
+
+func toModelListMatchers(matchers map[string]ListMatcher) map[string]models.ListMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	result := make(map[string]models.ListMatcher, len(matchers))
+	for name, matcher := range matchers {
+		result[name] = models.ListMatcher{MatchType: string(matcher.MatchType), Values: matcher.Values}
+	}
+
+	return result
+}
+
+func toBodyMatchers(matchers []models.BodyMatcher) []BodyMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	result := make([]BodyMatcher, 0, len(matchers))
+	for _, matcher := range matchers {
+		bodyMatcher := BodyMatcher{
+			MatchType:  BodyMatchType(matcher.MatchType),
+			Value:      matcher.Value,
+			Expression: matcher.Expression,
+			FormFields: toValueMatchers(matcher.FormFields),
+		}
+
+		if matcher.Matcher != nil {
+			bodyMatcher.Matcher = &ValueMatcher{MatchType: MatchType(matcher.Matcher.MatchType), Value: matcher.Matcher.Value}
+		}
+
+		result = append(result, bodyMatcher)
+	}
+
+	return result
+}
+
+func toModelBodyMatchers(matchers []BodyMatcher) []models.BodyMatcher {
+	if matchers == nil {
+		return nil
+	}
+
+	result := make([]models.BodyMatcher, 0, len(matchers))
+	for _, matcher := range matchers {
+		bodyMatcher := models.BodyMatcher{
+			MatchType:  string(matcher.MatchType),
+			Value:      matcher.Value,
+			Expression: matcher.Expression,
+			FormFields: toModelValueMatchers(matcher.FormFields),
+		}
+
+		if matcher.Matcher != nil {
+			bodyMatcher.Matcher = &models.ValueMatcher{MatchType: string(matcher.Matcher.MatchType), Value: matcher.Matcher.Value}
+		}
+
+		result = append(result, bodyMatcher)
+	}
+
+	return result
+}
+
+func newJournalEntry(c *gin.Context, body []byte) JournalEntry {
+	return JournalEntry{
+		Timestamp:   time.Now(),
+		Method:      c.Request.Method,
+		URL:         c.Request.URL.String(),
+		Path:        c.Request.URL.Path,
+		QueryParams: c.Request.URL.Query(),
+		Headers:     c.Request.Header.Clone(),
+		Body:        string(body),
+
+		ClientCertSubject: clientCertSubject(c.Request),
+	}
+}
+
+func toModelJournalEntry(entry *JournalEntry) models.JournalEntry {
+	return models.JournalEntry{
+		ID:            entry.ID,
+		Timestamp:     entry.Timestamp,
+		Method:        entry.Method,
+		URL:           entry.URL,
+		Path:          entry.Path,
+		QueryParams:   entry.QueryParams,
+		Headers:       entry.Headers,
+		Body:          entry.Body,
+		MatchedStubID: entry.MatchedEndpointID,
+
+		ClientCertSubject: entry.ClientCertSubject,
+		ContractErrors:    entry.ContractErrors,
+	}
+}
+
+func toModelViolation(violation *Violation) models.Violation {
+	return models.Violation{
+		Timestamp: violation.Timestamp,
+		Method:    violation.Method,
+		URL:       violation.URL,
+		Operation: violation.Operation,
+		Errors:    violation.Errors,
+	}
+}
+
+func toModelNearMisses(nearMisses []NearMiss) []models.NearMiss {
+	result := make([]models.NearMiss, 0, len(nearMisses))
+	for _, nearMiss := range nearMisses {
+		result = append(result, models.NearMiss{
+			StubID:     nearMiss.ID,
+			Path:       nearMiss.EndpointID.Path,
+			HTTPMethod: nearMiss.EndpointID.HTTPMethod,
+			Mismatches: nearMiss.Mismatches,
+		})
+	}
+
+	return result
+}
+
+// parseJournalFilter parses the journal filter from the query parameters path, method, header
+// (repeatable, formatted as "Name:Value"), since and until (RFC 3339).
+func parseJournalFilter(c *gin.Context) (JournalFilter, error) {
+	filter := JournalFilter{
+		Path:    c.Query("path"),
+		Method:  c.Query("method"),
+		Headers: make(map[string]string),
+	}
+
+	for _, header := range c.QueryArray("header") {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return JournalFilter{}, fmt.Errorf("invalid header filter %q, expected Name:Value", header)
+		}
+
+		filter.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	var err error
+
+	if since := c.Query("since"); since != "" {
+		filter.Since, err = time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			return JournalFilter{}, fmt.Errorf("invalid since filter: %w", err)
+		}
+	}
+
+	if until := c.Query("until"); until != "" {
+		filter.Until, err = time.Parse(time.RFC3339Nano, until)
+		if err != nil {
+			return JournalFilter{}, fmt.Errorf("invalid until filter: %w", err)
+		}
 	}
+
+	return filter, nil
 }
 
 func flattenQueryParams(c *gin.Context) map[string]string {
@@ -174,7 +1387,21 @@ func flattenHeaders(c *gin.Context) map[string]string {
 	return result
 }
 
-func logRequestContext(c *gin.Context) {
+// readRequestBody reads the complete request body and restores it, so it can be read again.
+func readRequestBody(c *gin.Context) []byte {
+	body, err := c.GetRawData()
+	if err != nil {
+		log.WithError(err).Error("unable to read request body")
+
+		return nil
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+	return body
+}
+
+func logRequestContext(c *gin.Context, body []byte, maxBodyBytes int) {
 	var requestInfo strings.Builder
 
 	requestInfo.WriteString(fmt.Sprintf("Request Method: %s\n", c.Request.Method))
@@ -205,15 +1432,12 @@ func logRequestContext(c *gin.Context) {
 		}
 	}
 
-	body, err := c.GetRawData()
-	if err == nil && len(body) > 0 {
-		if len(body) > maxBodySizeBytes {
-			body = body[:maxBodySizeBytes]
+	if len(body) > 0 {
+		if len(body) > maxBodyBytes {
+			body = body[:maxBodyBytes]
 		}
 
 		requestInfo.WriteString(fmt.Sprintf("Body Content:\n%s\n", string(body)))
-
-		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
 	}
 
 	log.WithFields(log.Fields{"context": requestInfo.String()}).Info("log request")
@@ -0,0 +1,144 @@
+package stubserver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamMode represents the way the chunks of a stream are written.
+type StreamMode string
+
+const (
+	// StreamModeSSE writes every chunk as a Server-Sent Event.
+	StreamModeSSE StreamMode = "sse"
+	// StreamModeChunked writes every chunk as is, using chunked transfer encoding.
+	StreamModeChunked StreamMode = "chunked"
+)
+
+const eventStreamContentType = "text/event-stream"
+
+// Stream describes a response body that is written as a series of chunks instead of at once.
+type Stream struct {
+	Mode   StreamMode
+	Chunks []StreamChunk
+	// HoldOpen keeps the connection open after the last chunk until the client disconnects.
+	HoldOpen bool
+}
+
+// StreamChunk represents a chunk of a stream, or an event when the stream is sent as SSE.
+type StreamChunk struct {
+	// Delay is the time to wait before the chunk is written.
+	Delay time.Duration
+	Data  string
+	// ID and Event are the id and event fields of a Server-Sent Event.
+	ID    string
+	Event string
+}
+
+func validateStream(ep *EndpointConfiguration) error {
+	stream := ep.Stream
+	if stream == nil {
+		return nil
+	}
+
+	switch stream.Mode {
+	case StreamModeSSE, StreamModeChunked:
+	default:
+		return fmt.Errorf("invalid stream mode: %s", stream.Mode)
+	}
+
+	if ep.ResponseBody != "" || ep.ResponseBodyBase64 != "" || ep.ResponseBodyFile != "" || len(ep.Responses) > 0 {
+		return fmt.Errorf("a stream cannot be combined with a response body or sequence")
+	}
+
+	for i, chunk := range stream.Chunks {
+		if chunk.Delay < 0 {
+			return fmt.Errorf("stream chunk %d: delay must not be negative", i)
+		}
+
+		if stream.Mode == StreamModeChunked && (chunk.ID != "" || chunk.Event != "") {
+			return fmt.Errorf("stream chunk %d: id and event are only supported for SSE", i)
+		}
+
+		if strings.ContainsAny(chunk.ID+chunk.Event, "\r\n") {
+			return fmt.Errorf("stream chunk %d: id and event must not contain line breaks", i)
+		}
+	}
+
+	return nil
+}
+
+// writeStream writes the chunks of the stream, waiting for the delay of every chunk, and flushes
+// after each of them. It stops early when the client disconnects. started is called once the
+// headers have been flushed.
+func writeStream(c *gin.Context, statusCode int, stream *Stream, started func()) {
+	if stream.Mode == StreamModeSSE {
+		if c.Writer.Header().Get(contentTypeHeader) == "" {
+			c.Header(contentTypeHeader, eventStreamContentType)
+		}
+
+		c.Header("Cache-Control", "no-cache")
+	}
+
+	c.Status(statusCode)
+	c.Writer.Flush()
+	started()
+
+	ctx := c.Request.Context()
+	index := 0
+
+	c.Stream(func(w io.Writer) bool {
+		if index == len(stream.Chunks) {
+			if stream.HoldOpen {
+				<-ctx.Done()
+			}
+
+			return false
+		}
+
+		chunk := stream.Chunks[index]
+		index++
+
+		sleep(ctx, chunk.Delay)
+
+		if ctx.Err() != nil {
+			return false
+		}
+
+		_, err := io.WriteString(w, formatChunk(stream.Mode, &chunk))
+
+		return err == nil
+	})
+}
+
+func formatChunk(mode StreamMode, chunk *StreamChunk) string {
+	if mode != StreamModeSSE {
+		return chunk.Data
+	}
+
+	var event strings.Builder
+
+	if chunk.ID != "" {
+		event.WriteString("id: " + chunk.ID + "\n")
+	}
+
+	if chunk.Event != "" {
+		event.WriteString("event: " + chunk.Event + "\n")
+	}
+
+	for line := range strings.Lines(strings.ReplaceAll(chunk.Data, "\r\n", "\n")) {
+		event.WriteString("data: " + strings.TrimSuffix(line, "\n") + "\n")
+	}
+
+	if chunk.Data == "" {
+		event.WriteString("data: \n")
+	}
+
+	event.WriteString("\n")
+
+	return event.String()
+}
@@ -0,0 +1,129 @@
+package stubserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStream(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        EndpointConfiguration
+		errorContains string
+	}{
+		{
+			name:   "valid SSE stream",
+			config: EndpointConfiguration{Stream: &Stream{Mode: StreamModeSSE, Chunks: []StreamChunk{{ID: "1", Event: "update", Data: "{}"}}}},
+		},
+		{
+			name:          "invalid mode",
+			config:        EndpointConfiguration{Stream: &Stream{Mode: "websocket"}},
+			errorContains: "invalid stream mode: websocket",
+		},
+		{
+			name:          "with response body",
+			config:        EndpointConfiguration{ResponseBody: "{}", Stream: &Stream{Mode: StreamModeChunked}},
+			errorContains: "a stream cannot be combined with a response body or sequence",
+		},
+		{
+			name:          "negative delay",
+			config:        EndpointConfiguration{Stream: &Stream{Mode: StreamModeChunked, Chunks: []StreamChunk{{Delay: -time.Second}}}},
+			errorContains: "stream chunk 0: delay must not be negative",
+		},
+		{
+			name:          "event of chunked stream",
+			config:        EndpointConfiguration{Stream: &Stream{Mode: StreamModeChunked, Chunks: []StreamChunk{{Event: "update"}}}},
+			errorContains: "stream chunk 0: id and event are only supported for SSE",
+		},
+		{
+			name:          "line break in id",
+			config:        EndpointConfiguration{Stream: &Stream{Mode: StreamModeSSE, Chunks: []StreamChunk{{ID: "1\n2"}}}},
+			errorContains: "stream chunk 0: id and event must not contain line breaks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStream(&tt.config)
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestFormatChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     StreamMode
+		chunk    StreamChunk
+		expected string
+	}{
+		{name: "chunked", mode: StreamModeChunked, chunk: StreamChunk{Data: "{\"id\":1}\n"}, expected: "{\"id\":1}\n"},
+		{name: "SSE data", mode: StreamModeSSE, chunk: StreamChunk{Data: "hello"}, expected: "data: hello\n\n"},
+		{
+			name:     "SSE with id and event",
+			mode:     StreamModeSSE,
+			chunk:    StreamChunk{ID: "7", Event: "update", Data: "line 1\r\nline 2"},
+			expected: "id: 7\nevent: update\ndata: line 1\ndata: line 2\n\n",
+		},
+		{name: "SSE without data", mode: StreamModeSSE, chunk: StreamChunk{Event: "ping"}, expected: "event: ping\ndata: \n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, formatChunk(tt.mode, &tt.chunk))
+		})
+	}
+}
+
+func TestServeStreamHeldOpen(t *testing.T) {
+	server := NewServer()
+	testServer := httptest.NewServer(server.Router)
+
+	defer testServer.Close()
+
+	require.NoError(t, server.namespaces.get(DefaultNamespace).responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID:         EndpointID{Path: "/events", HTTPMethod: http.MethodGet},
+		ResponseStatusCode: http.StatusOK,
+		Stream: &Stream{
+			Mode:     StreamModeSSE,
+			Chunks:   []StreamChunk{{Data: "first"}, {Delay: 10 * time.Millisecond, Data: "second"}},
+			HoldOpen: true,
+		},
+	}))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/events", nil)
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, eventStreamContentType, resp.Header.Get(contentTypeHeader))
+
+	expected := "data: first\n\ndata: second\n\n"
+	received := make([]byte, len(expected))
+
+	_, err = io.ReadFull(resp.Body, received)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(received))
+
+	cancel()
+
+	_, err = resp.Body.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,212 @@
+package stubserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the data that is available to response templates, e.g. {{.PathParams.id}},
+// {{.Query.page}}, {{index .Headers "X-Request-Id"}} or {{.JSON.name}}.
+type TemplateData struct {
+	Method       string
+	Path         string
+	PathSegments []string
+	PathParams   map[string]string
+	Query        map[string]string
+	Headers      map[string]string
+	Body         string
+	// JSON contains the request body decoded as JSON, or nil if the body is not valid JSON.
+	JSON any
+}
+
+// NewTemplateData creates the template data for an incoming request and its match result.
+func NewTemplateData(ei *EndpointID, pathParams map[string]string) *TemplateData {
+	data := &TemplateData{
+		Method:       ei.HTTPMethod,
+		Path:         ei.Path,
+		PathSegments: splitPath(ei.Path),
+		PathParams:   pathParams,
+		Query:        ei.QueryParamsToMatch,
+		Headers:      ei.HeadersToMatch,
+		Body:         string(ei.Body),
+	}
+
+	var document any
+
+	err := json.Unmarshal(ei.Body, &document)
+	if err == nil {
+		data.JSON = document
+	}
+
+	return data
+}
+
+// templateFuncs returns the helper functions that are available to response templates.
+func templateFuncs(data *TemplateData) template.FuncMap {
+	return template.FuncMap{
+		"uuid":         newUUID,
+		"now":          now,
+		"randomInt":    randomInt,
+		"base64Encode": base64Encode,
+		"base64Decode": base64Decode,
+		"jsonPath": func(expression string) (string, error) {
+			if data == nil || data.JSON == nil {
+				return "", nil
+			}
+
+			results, err := evaluateJSONPath(expression, data.JSON)
+			if err != nil || len(results) == 0 {
+				return "", err
+			}
+
+			return jsonValueToString(results[0]), nil
+		},
+	}
+}
+
+// validateTemplates checks that the templates of all responses of a templated endpoint parse.
+func validateTemplates(ep *EndpointConfiguration) error {
+	if !ep.ResponseTemplating {
+		return nil
+	}
+
+	responses := ep.Responses
+	if len(responses) == 0 {
+		responses = []Response{defaultResponse(ep)}
+	}
+
+	for _, response := range responses {
+		texts := []string{response.Body, response.StatusCodeTemplate}
+		for _, value := range response.Headers {
+			texts = append(texts, value)
+		}
+
+		for _, text := range texts {
+			_, err := template.New("response").Funcs(templateFuncs(nil)).Parse(text)
+			if err != nil {
+				return fmt.Errorf("invalid response template: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// renderResponse executes the templates in the body, headers and status code of the response.
+func renderResponse(response Response, data *TemplateData) (Response, error) {
+	body, err := renderTemplate(response.Body, data)
+	if err != nil {
+		return Response{}, err
+	}
+
+	headers := make(map[string]string, len(response.Headers))
+
+	for key, value := range response.Headers {
+		headers[key], err = renderTemplate(value, data)
+		if err != nil {
+			return Response{}, err
+		}
+	}
+
+	rendered := Response{
+		Headers:    headers,
+		Body:       body,
+		BodyBase64: response.BodyBase64,
+		BodyFile:   response.BodyFile,
+		StatusCode: response.StatusCode,
+		Times:      response.Times,
+	}
+
+	if response.StatusCodeTemplate != "" {
+		statusCode, err := renderTemplate(response.StatusCodeTemplate, data)
+		if err != nil {
+			return Response{}, err
+		}
+
+		rendered.StatusCode, err = strconv.Atoi(strings.TrimSpace(statusCode))
+		if err != nil {
+			return Response{}, fmt.Errorf("invalid templated status code %q: %w", statusCode, err)
+		}
+	}
+
+	return rendered, nil
+}
+
+func renderTemplate(text string, data *TemplateData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("response").Funcs(templateFuncs(data)).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse response template: %w", err)
+	}
+
+	var buffer bytes.Buffer
+
+	err = tmpl.Execute(&buffer, data)
+	if err != nil {
+		return "", fmt.Errorf("unable to execute response template: %w", err)
+	}
+
+	return buffer.String(), nil
+}
+
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	// Set the version (4) and variant (RFC 4122) bits.
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// now returns the current UTC time, formatted with the given Go layout or RFC 3339 by default.
+func now(layout ...string) string {
+	if len(layout) > 0 {
+		return time.Now().UTC().Format(layout[0])
+	}
+
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// randomInt returns a random integer in the range [minimum, maximum].
+func randomInt(minimum, maximum int) (int, error) {
+	if maximum < minimum {
+		return 0, fmt.Errorf("invalid range for randomInt: %d > %d", minimum, maximum)
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(maximum-minimum+1)))
+	if err != nil {
+		return 0, err
+	}
+
+	return minimum + int(n.Int64()), nil
+}
+
+func base64Encode(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+func base64Decode(value string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
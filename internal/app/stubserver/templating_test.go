@@ -0,0 +1,134 @@
+package stubserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTemplateData() *TemplateData {
+	return NewTemplateData(&EndpointID{
+		Path:               "/api/v1/users/42/orders",
+		HTTPMethod:         "POST",
+		QueryParamsToMatch: map[string]string{"page": "3"},
+		HeadersToMatch:     map[string]string{"X-Request-Id": "req-1"},
+		Body:               []byte(`{"order":{"id":"order-7","items":[{"sku":"a"}]}}`),
+	}, map[string]string{"userId": "42"})
+}
+
+//nolint:funlen
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			template: `{"status":"ok"}`,
+			expected: `{"status":"ok"}`,
+		},
+		{
+			name:     "path params",
+			template: `{"userId":"{{.PathParams.userId}}"}`,
+			expected: `{"userId":"42"}`,
+		},
+		{
+			name:     "path segments",
+			template: `{{index .PathSegments 4}}`,
+			expected: `orders`,
+		},
+		{
+			name:     "method, query and headers",
+			template: `{{.Method}} {{.Query.page}} {{index .Headers "X-Request-Id"}}`,
+			expected: `POST 3 req-1`,
+		},
+		{
+			name:     "missing query param",
+			template: `[{{.Query.missing}}]`,
+			expected: `[]`,
+		},
+		{
+			name:     "json body",
+			template: `{{.JSON.order.id}}`,
+			expected: `order-7`,
+		},
+		{
+			name:     "json path helper",
+			template: `{{jsonPath "$.order.items[0].sku"}}`,
+			expected: `a`,
+		},
+		{
+			name:     "base64 helpers",
+			template: `{{base64Encode "foo"}} {{base64Decode "YmFy"}}`,
+			expected: `Zm9v bar`,
+		},
+		{
+			name:     "now with layout",
+			template: `{{len (now "2006")}}`,
+			expected: `4`,
+		},
+		{
+			name:     "random int in range",
+			template: `{{randomInt 5 5}}`,
+			expected: `5`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderTemplate(tt.template, newTestTemplateData())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRenderTemplateUUID(t *testing.T) {
+	first, err := renderTemplate(`{{uuid}}`, newTestTemplateData())
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, first)
+
+	second, err := renderTemplate(`{{uuid}}`, newTestTemplateData())
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
+
+func TestRenderResponse(t *testing.T) {
+	response := Response{
+		Headers:            map[string]string{"Location": "/api/v1/users/{{.PathParams.userId}}"},
+		Body:               `{"id":"{{.PathParams.userId}}"}`,
+		StatusCode:         200,
+		StatusCodeTemplate: `{{if eq .Method "POST"}}201{{else}}200{{end}}`,
+	}
+
+	rendered, err := renderResponse(response, newTestTemplateData())
+	require.NoError(t, err)
+	assert.Equal(t, Response{
+		Headers:    map[string]string{"Location": "/api/v1/users/42"},
+		Body:       `{"id":"42"}`,
+		StatusCode: 201,
+	}, rendered)
+
+	response.StatusCodeTemplate = "created"
+
+	_, err = renderResponse(response, newTestTemplateData())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid templated status code")
+}
+
+func TestValidateTemplates(t *testing.T) {
+	ep := EndpointConfiguration{
+		EndpointID:         EndpointID{Path: "/api/v1/test", HTTPMethod: "GET"},
+		ResponseBody:       `{{.PathParams.id`,
+		ResponseTemplating: true,
+	}
+
+	err := ValidateEndpoint(ep)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid response template")
+
+	ep.ResponseTemplating = false
+	assert.NoError(t, ValidateEndpoint(ep), "templates are not parsed when templating is disabled")
+}
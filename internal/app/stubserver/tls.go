@@ -0,0 +1,290 @@
+package stubserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/schubergphilis/mcvs-integrationtest-services/pkg/stubserver/models"
+)
+
+const (
+	certificateValidity = 365 * 24 * time.Hour
+	serialNumberBits    = 128
+)
+
+// ClientAuth values select how client certificates are handled.
+const (
+	// ClientAuthNone does not ask for client certificates.
+	ClientAuthNone = "none"
+	// ClientAuthRequest asks for a client certificate without verifying it.
+	ClientAuthRequest = "request"
+	// ClientAuthVerifyIfGiven verifies the client certificate when one is presented.
+	ClientAuthVerifyIfGiven = "verifyIfGiven"
+	// ClientAuthRequire requires a client certificate signed by a client CA.
+	ClientAuthRequire = "require"
+)
+
+// TLSConfig represents the TLS configuration of the stub server.
+type TLSConfig struct {
+	// CertFile and KeyFile hold the server certificate. When they are empty, a CA and a server
+	// certificate for SANs are generated.
+	CertFile string
+	KeyFile  string
+	// SANs are the DNS names and IP addresses of the generated server certificate.
+	SANs []string
+
+	// ClientAuth is one of the ClientAuth values and defaults to ClientAuthNone.
+	ClientAuth string
+	// ClientCAFile holds the CAs that client certificates are verified with. The generated CA is
+	// used when it is empty.
+	ClientCAFile string
+}
+
+// certificateAuthority is a CA that the stub server generates to issue its own certificates.
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// ConfigureTLS returns the configuration to serve HTTPS with. A CA that is generated is made
+// available for download from the admin API.
+func (s *Server) ConfigureTLS(config TLSConfig) (*tls.Config, error) {
+	clientAuth, err := parseClientAuth(config.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: clientAuth,
+	}
+
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load server certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	} else {
+		ca, err := newCertificateAuthority()
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := ca.issue(newLeafTemplate("mcvs-stub-server", config.SANs, x509.ExtKeyUsageServerAuth))
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		s.ca = ca
+	}
+
+	if clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert {
+		tlsConfig.ClientCAs, err = s.clientCAs(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func parseClientAuth(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	}
+
+	return tls.NoClientCert, fmt.Errorf("invalid client auth: %s", clientAuth)
+}
+
+func (s *Server) clientCAs(clientCAFile string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	if clientCAFile == "" {
+		if s.ca == nil {
+			return nil, fmt.Errorf("a client CA file is required to verify client certificates")
+		}
+
+		pool.AddCert(s.ca.cert)
+
+		return pool, nil
+	}
+
+	data, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client CA file: %w", err)
+	}
+
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in client CA file: %s", clientCAFile)
+	}
+
+	return pool, nil
+}
+
+func (s *Server) getCA(c *gin.Context) {
+	if s.ca == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "No CA was generated"})
+
+		return
+	}
+
+	c.Data(http.StatusOK, "application/x-pem-file", s.ca.pem)
+}
+
+// issueClientCertificate issues a client certificate with the generated CA, which verifies client
+// certificates when no client CA file is configured.
+func (s *Server) issueClientCertificate(c *gin.Context) {
+	if s.ca == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "No CA was generated"})
+
+		return
+	}
+
+	var request models.ClientCertificateRequest
+
+	err := c.ShouldBindJSON(&request)
+	if err != nil || request.CommonName == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "A common name is required"})
+
+		return
+	}
+
+	cert, err := s.ca.issue(newLeafTemplate(request.CommonName, nil, x509.ExtKeyUsageClientAuth))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+
+		return
+	}
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: fmt.Sprintf("unable to encode private key: %v", err)})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ClientCertificateResponse{
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})),
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})),
+	})
+}
+
+func newCertificateAuthority() (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate CA key: %w", err)
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: "mcvs-stub-server CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(certificateValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create CA certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CA certificate: %w", err)
+	}
+
+	return &certificateAuthority{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}, nil
+}
+
+// issue creates a certificate from the template that is signed by the CA.
+func (ca *certificateAuthority) issue(template *x509.Certificate) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("unable to generate key: %w", err)
+	}
+
+	template.SerialNumber, err = newSerialNumber()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("unable to create certificate: %w", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// newLeafTemplate returns the template of a certificate for the given DNS names and IP addresses.
+func newLeafTemplate(commonName string, sans []string, usage x509.ExtKeyUsage) *x509.Certificate {
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(certificateValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	}
+
+	for _, san := range sans {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, san)
+		}
+	}
+
+	return template
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialNumberBits))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate serial number: %w", err)
+	}
+
+	return serialNumber, nil
+}
+
+// clientCertSubject returns the subject of the client certificate of the request, if any.
+func clientCertSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ""
+	}
+
+	return r.TLS.PeerCertificates[0].Subject.String()
+}
@@ -0,0 +1,154 @@
+package stubserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/schubergphilis/mcvs-integrationtest-services/pkg/stubserver/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClientAuth(t *testing.T) {
+	tests := map[string]tls.ClientAuthType{
+		"":                      tls.NoClientCert,
+		ClientAuthNone:          tls.NoClientCert,
+		ClientAuthRequest:       tls.RequestClientCert,
+		ClientAuthVerifyIfGiven: tls.VerifyClientCertIfGiven,
+		ClientAuthRequire:       tls.RequireAndVerifyClientCert,
+	}
+
+	for clientAuth, expected := range tests {
+		actual, err := parseClientAuth(clientAuth)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	_, err := parseClientAuth("always")
+	assert.ErrorContains(t, err, "invalid client auth: always")
+}
+
+func TestConfigureTLSErrors(t *testing.T) {
+	_, err := NewServer().ConfigureTLS(TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"})
+	assert.ErrorContains(t, err, "unable to load server certificate")
+
+	_, err = NewServer().clientCAs("")
+	assert.ErrorContains(t, err, "a client CA file is required")
+
+	_, err = NewServer().clientCAs("missing.pem")
+	assert.ErrorContains(t, err, "unable to read client CA file")
+}
+
+//nolint:funlen
+func TestServeTLSWithClientCertificates(t *testing.T) {
+	server := NewServer()
+
+	tlsConfig, err := server.ConfigureTLS(TLSConfig{SANs: []string{"127.0.0.1"}, ClientAuth: ClientAuthVerifyIfGiven})
+	require.NoError(t, err)
+
+	testServer := httptest.NewUnstartedServer(server.Router)
+	testServer.TLS = tlsConfig
+	testServer.StartTLS()
+
+	defer testServer.Close()
+
+	responseManager := server.namespaces.get(DefaultNamespace).responseManager
+	require.NoError(t, responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{
+			Path:                     "/api/orders",
+			HTTPMethod:               http.MethodGet,
+			ClientCertSubjectMatcher: &ValueMatcher{MatchType: MatchTypeExact, Value: "CN=orders"},
+		},
+		ResponseBody: "orders",
+	}))
+	require.NoError(t, responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID:   EndpointID{Path: "/api/orders", HTTPMethod: http.MethodGet},
+		ResponseBody: "anonymous",
+	}))
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(server.ca.pem))
+
+	clientCert := issueClientCertificate(t, server, "orders")
+
+	tests := []struct {
+		name         string
+		certificates []tls.Certificate
+		expected     string
+	}{
+		{name: "with client certificate", certificates: []tls.Certificate{clientCert}, expected: "orders"},
+		{name: "without client certificate", expected: "anonymous"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				RootCAs:      roots,
+				Certificates: tt.certificates,
+			}}}
+
+			resp, err := client.Get(testServer.URL + "/api/orders")
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(body))
+		})
+	}
+
+	entries := server.namespaces.get(DefaultNamespace).journal.Find(JournalFilter{})
+	require.Len(t, entries, 2)
+	assert.Equal(t, "CN=orders", entries[0].ClientCertSubject)
+	assert.Empty(t, entries[1].ClientCertSubject)
+
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, BaseURLPath+CAEndpoint, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, string(server.ca.pem), recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	NewServer().Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, BaseURLPath+CAEndpoint, nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func issueClientCertificate(t *testing.T, server *Server, commonName string) tls.Certificate {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, BaseURLPath+ClientCertificatesEndpoint,
+		strings.NewReader(`{"commonName":"`+commonName+`"}`)))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response models.ClientCertificateResponse
+
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+
+	cert, err := tls.X509KeyPair([]byte(response.Certificate), []byte(response.PrivateKey))
+	require.NoError(t, err)
+
+	return cert
+}
+
+func TestIssueClientCertificateErrors(t *testing.T) {
+	server := NewServer()
+
+	recorder := httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, BaseURLPath+ClientCertificatesEndpoint, strings.NewReader(`{"commonName":"orders"}`)))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	_, err := server.ConfigureTLS(TLSConfig{})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	server.Router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, BaseURLPath+ClientCertificatesEndpoint, strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
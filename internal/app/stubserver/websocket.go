@@ -0,0 +1,398 @@
+package stubserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+// webSocketCloseTimeout is how long the server waits for the client to acknowledge a close frame.
+const webSocketCloseTimeout = 5 * time.Second
+
+// maxCloseReasonBytes is the longest reason that fits in a close frame.
+const maxCloseReasonBytes = 123
+
+// WebSocket describes the conversation of an endpoint that upgrades requests to WebSocket.
+type WebSocket struct {
+	// OnConnect are the messages that are sent when the connection is established.
+	OnConnect []WebSocketMessage
+	// Replies answer the received text messages. The first reply whose matcher matches the message
+	// is sent.
+	Replies []WebSocketReply
+	// Close optionally closes the connection once the conversation has lasted its delay.
+	Close *WebSocketClose
+}
+
+// WebSocketMessage represents a text message that is sent after the delay.
+type WebSocketMessage struct {
+	Delay time.Duration
+	Data  string
+}
+
+// WebSocketReply represents the messages that are sent in reply to a matching message.
+type WebSocketReply struct {
+	Matcher  ValueMatcher
+	Messages []WebSocketMessage
+}
+
+// WebSocketClose represents the close frame that ends the conversation.
+type WebSocketClose struct {
+	Delay  time.Duration
+	Code   int
+	Reason string
+}
+
+// WebSocketFrame represents a data frame that a WebSocket endpoint received.
+type WebSocketFrame struct {
+	// ID is a sequence number that reflects the order in which frames were received.
+	ID        int64
+	Timestamp time.Time
+	StubID    string
+	// Connection identifies the WebSocket connection that received the frame.
+	Connection int64
+	Binary     bool
+	Data       []byte
+}
+
+// WebSocketFrameJournal keeps the most recent frames received by WebSocket endpoints in memory.
+type WebSocketFrameJournal struct {
+	mu             sync.RWMutex
+	frames         []WebSocketFrame
+	maxSize        int
+	lastID         int64
+	lastConnection int64
+}
+
+// NewWebSocketFrameJournal creates a new instance of WebSocketFrameJournal that keeps at most
+// maxSize frames.
+func NewWebSocketFrameJournal(maxSize int) *WebSocketFrameJournal {
+	if maxSize <= 0 {
+		maxSize = defaultJournalSize
+	}
+
+	return &WebSocketFrameJournal{frames: make([]WebSocketFrame, 0), maxSize: maxSize}
+}
+
+// newConnection returns the identifier for a new WebSocket connection.
+func (j *WebSocketFrameJournal) newConnection() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.lastConnection++
+
+	return j.lastConnection
+}
+
+// Record adds a frame to the journal, dropping the oldest frame when the journal is full.
+func (j *WebSocketFrameJournal) Record(frame WebSocketFrame) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.frames) >= j.maxSize {
+		j.frames = j.frames[len(j.frames)-j.maxSize+1:]
+	}
+
+	j.lastID++
+	frame.ID = j.lastID
+
+	j.frames = append(j.frames, frame)
+}
+
+// Find returns the frames received by the stub, or by every stub when stubID is empty, oldest first.
+func (j *WebSocketFrameJournal) Find(stubID string) []WebSocketFrame {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	frames := make([]WebSocketFrame, 0)
+
+	for _, frame := range j.frames {
+		if stubID == "" || frame.StubID == stubID {
+			frames = append(frames, frame)
+		}
+	}
+
+	return frames
+}
+
+// Clear removes all frames from the journal.
+func (j *WebSocketFrameJournal) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.frames = make([]WebSocketFrame, 0)
+}
+
+// isWebSocketUpgrade reports whether the headers ask to upgrade the connection to WebSocket.
+func isWebSocketUpgrade(headers map[string][]string) bool {
+	for _, value := range http.Header(headers).Values("Upgrade") {
+		if slices.ContainsFunc(strings.Split(value, ","), func(token string) bool {
+			return strings.EqualFold(strings.TrimSpace(token), "websocket")
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateWebSocket(ep *EndpointConfiguration) error {
+	if (ep.WebSocket != nil) != ep.EndpointID.WebSocket {
+		return fmt.Errorf("a WebSocket endpoint requires a WebSocket conversation")
+	}
+
+	script := ep.WebSocket
+	if script == nil {
+		return nil
+	}
+
+	if ep.EndpointID.HTTPMethod != http.MethodGet && ep.EndpointID.HTTPMethod != AnyHTTPMethod {
+		return fmt.Errorf("a WebSocket endpoint must use the GET method")
+	}
+
+	if ep.ResponseBody != "" || ep.ResponseBodyBase64 != "" || ep.ResponseBodyFile != "" || len(ep.Responses) > 0 || ep.Stream != nil {
+		return fmt.Errorf("a WebSocket conversation cannot be combined with a response body, sequence or stream")
+	}
+
+	err := validateWebSocketMessages(script.OnConnect)
+	if err != nil {
+		return fmt.Errorf("on connect: %w", err)
+	}
+
+	for i, reply := range script.Replies {
+		err = reply.Matcher.Validate()
+		if err != nil {
+			return fmt.Errorf("reply %d: %w", i, err)
+		}
+
+		err = validateWebSocketMessages(reply.Messages)
+		if err != nil {
+			return fmt.Errorf("reply %d: %w", i, err)
+		}
+	}
+
+	if script.Close != nil {
+		return validateWebSocketClose(script.Close)
+	}
+
+	return nil
+}
+
+func validateWebSocketMessages(messages []WebSocketMessage) error {
+	for i, message := range messages {
+		if message.Delay < 0 {
+			return fmt.Errorf("message %d: delay must not be negative", i)
+		}
+	}
+
+	return nil
+}
+
+func validateWebSocketClose(wsClose *WebSocketClose) error {
+	if wsClose.Delay < 0 {
+		return fmt.Errorf("close delay must not be negative")
+	}
+
+	switch {
+	case wsClose.Code < websocket.CloseNormalClosure || wsClose.Code > 4999,
+		wsClose.Code == websocket.CloseNoStatusReceived,
+		wsClose.Code == websocket.CloseAbnormalClosure,
+		wsClose.Code == websocket.CloseTLSHandshake:
+		return fmt.Errorf("invalid close code: %d", wsClose.Code)
+	}
+
+	if len(wsClose.Reason) > maxCloseReasonBytes {
+		return fmt.Errorf("close reason must not exceed %d bytes", maxCloseReasonBytes)
+	}
+
+	return nil
+}
+
+// webSocketSession runs the conversation of a WebSocket endpoint on an upgraded connection. A
+// single writer sends the queued messages, so replies never interleave and follow the order in
+// which the messages they answer were received.
+type webSocketSession struct {
+	conn       *websocket.Conn
+	wg         sync.WaitGroup
+	queueMu    sync.Mutex
+	queue      []WebSocketMessage
+	queued     chan struct{}
+	stubID     string
+	connection int64
+	frames     *WebSocketFrameJournal
+}
+
+// serveWebSocket upgrades the request and runs the conversation until either side closes the
+// connection. The upgrader replies with an error when the request cannot be upgraded; opened is
+// only called once the upgrade succeeded.
+func serveWebSocket(c *gin.Context, frames *WebSocketFrameJournal, stubID string, script *WebSocket, headers map[string]string, opened func()) {
+	responseHeader := make(http.Header, len(headers))
+	for key, value := range headers {
+		responseHeader.Set(key, value)
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, responseHeader)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{"urlPath": c.Request.URL.Path}).Error("unable to upgrade to WebSocket")
+
+		return
+	}
+
+	opened()
+
+	session := &webSocketSession{
+		conn:       conn,
+		queued:     make(chan struct{}, 1),
+		stubID:     stubID,
+		connection: frames.newConnection(),
+		frames:     frames,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	session.send(script.OnConnect)
+	session.wg.Go(func() {
+		session.write(ctx)
+	})
+
+	if script.Close != nil {
+		session.wg.Go(func() {
+			session.close(ctx, script.Close)
+		})
+	}
+
+	session.receive(ctx, script.Replies)
+
+	cancel()
+	session.wg.Wait()
+
+	err = conn.Close()
+	if err != nil {
+		log.WithError(err).Debug("unable to close WebSocket connection")
+	}
+}
+
+// receive records the received data frames and answers them until the connection is closed.
+func (s *webSocketSession) receive(ctx context.Context, replies []WebSocketReply) {
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		s.frames.Record(WebSocketFrame{
+			Timestamp:  time.Now(),
+			StubID:     s.stubID,
+			Connection: s.connection,
+			Binary:     messageType == websocket.BinaryMessage,
+			Data:       data,
+		})
+
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		for _, reply := range replies {
+			if reply.Matcher.Matches(string(data), true) {
+				s.send(reply.Messages)
+
+				break
+			}
+		}
+	}
+}
+
+// send queues the messages for the writer.
+func (s *webSocketSession) send(messages []WebSocketMessage) {
+	if len(messages) == 0 {
+		return
+	}
+
+	s.queueMu.Lock()
+	s.queue = append(s.queue, messages...)
+	s.queueMu.Unlock()
+
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+// write sends the queued messages one by one until the context is cancelled, waiting for the delay
+// of every message after sending the previous one.
+func (s *webSocketSession) write(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.queued:
+		}
+
+		for message, ok := s.dequeue(); ok; message, ok = s.dequeue() {
+			sleep(ctx, message.Delay)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			err := s.conn.WriteMessage(websocket.TextMessage, []byte(message.Data))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *webSocketSession) dequeue() (WebSocketMessage, bool) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	if len(s.queue) == 0 {
+		return WebSocketMessage{}, false
+	}
+
+	message := s.queue[0]
+	s.queue = s.queue[1:]
+
+	return message, true
+}
+
+// close sends the close frame after the delay and gives the client some time to acknowledge it.
+func (s *webSocketSession) close(ctx context.Context, wsClose *WebSocketClose) {
+	sleep(ctx, wsClose.Delay)
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	deadline := time.Now().Add(webSocketCloseTimeout)
+
+	// WriteControl may be called concurrently with the writer.
+	err := s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(wsClose.Code, wsClose.Reason), deadline)
+	if err != nil {
+		log.WithError(err).Debug("unable to send WebSocket close frame")
+	}
+
+	err = s.conn.SetReadDeadline(deadline)
+	if err != nil {
+		log.WithError(err).Debug("unable to set WebSocket read deadline")
+	}
+}
+
+func encodeFrameData(frame *WebSocketFrame) string {
+	if frame.Binary {
+		return base64.StdEncoding.EncodeToString(frame.Data)
+	}
+
+	return string(frame.Data)
+}
@@ -0,0 +1,225 @@
+package stubserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWebSocket(t *testing.T) {
+	webSocketID := EndpointID{Path: "/ws", HTTPMethod: http.MethodGet, WebSocket: true}
+
+	tests := []struct {
+		name          string
+		config        EndpointConfiguration
+		errorContains string
+	}{
+		{
+			name: "valid conversation",
+			config: EndpointConfiguration{EndpointID: webSocketID, WebSocket: &WebSocket{
+				OnConnect: []WebSocketMessage{{Data: "hello"}},
+				Replies:   []WebSocketReply{{Matcher: ValueMatcher{MatchType: MatchTypeContains, Value: "ping"}}},
+				Close:     &WebSocketClose{Code: 4000},
+			}},
+		},
+		{
+			name:          "missing conversation",
+			config:        EndpointConfiguration{EndpointID: webSocketID},
+			errorContains: "a WebSocket endpoint requires a WebSocket conversation",
+		},
+		{
+			name: "POST method",
+			config: EndpointConfiguration{
+				EndpointID: EndpointID{Path: "/ws", HTTPMethod: http.MethodPost, WebSocket: true},
+				WebSocket:  &WebSocket{},
+			},
+			errorContains: "a WebSocket endpoint must use the GET method",
+		},
+		{
+			name:          "with response body",
+			config:        EndpointConfiguration{EndpointID: webSocketID, ResponseBody: "{}", WebSocket: &WebSocket{}},
+			errorContains: "a WebSocket conversation cannot be combined with a response body, sequence or stream",
+		},
+		{
+			name: "negative delay",
+			config: EndpointConfiguration{EndpointID: webSocketID, WebSocket: &WebSocket{
+				OnConnect: []WebSocketMessage{{Delay: -time.Second}},
+			}},
+			errorContains: "on connect: message 0: delay must not be negative",
+		},
+		{
+			name: "invalid reply matcher",
+			config: EndpointConfiguration{EndpointID: webSocketID, WebSocket: &WebSocket{
+				Replies: []WebSocketReply{{Matcher: ValueMatcher{MatchType: MatchTypeRegex, Value: "("}}},
+			}},
+			errorContains: "reply 0: invalid regular expression",
+		},
+		{
+			name:          "reserved close code",
+			config:        EndpointConfiguration{EndpointID: webSocketID, WebSocket: &WebSocket{Close: &WebSocketClose{Code: 1006}}},
+			errorContains: "invalid close code: 1006",
+		},
+		{
+			name: "long close reason",
+			config: EndpointConfiguration{EndpointID: webSocketID, WebSocket: &WebSocket{
+				Close: &WebSocketClose{Code: 1000, Reason: strings.Repeat("x", 124)},
+			}},
+			errorContains: "close reason must not exceed 123 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebSocket(&tt.config)
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorContains)
+			}
+		})
+	}
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	assert.True(t, isWebSocketUpgrade(http.Header{"Upgrade": {"websocket"}}))
+	assert.True(t, isWebSocketUpgrade(http.Header{"Upgrade": {"h2c, WebSocket"}}))
+	assert.False(t, isWebSocketUpgrade(http.Header{"Upgrade": {"h2c"}}))
+	assert.False(t, isWebSocketUpgrade(http.Header{}))
+}
+
+//nolint:funlen
+func TestServeWebSocket(t *testing.T) {
+	server := NewServer()
+	testServer := httptest.NewServer(server.Router)
+
+	defer testServer.Close()
+
+	ns := server.namespaces.get(DefaultNamespace)
+
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		ID:         "quotes",
+		EndpointID: EndpointID{Path: "/quotes", HTTPMethod: http.MethodGet, WebSocket: true},
+		WebSocket: &WebSocket{
+			OnConnect: []WebSocketMessage{{Data: "welcome"}},
+			Replies: []WebSocketReply{
+				{
+					Matcher:  ValueMatcher{MatchType: MatchTypeContains, Value: "subscribe"},
+					Messages: []WebSocketMessage{{Data: "subscribed"}, {Delay: 10 * time.Millisecond, Data: "quote"}},
+				},
+			},
+			Close: &WebSocketClose{Delay: 100 * time.Millisecond, Code: 4001, Reason: "market closed"},
+		},
+	}))
+	require.NoError(t, ns.responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID:         EndpointID{Path: "/quotes", HTTPMethod: http.MethodGet},
+		ResponseBody:       "plain",
+		ResponseStatusCode: http.StatusOK,
+	}))
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(testServer.URL, "http")+"/quotes", nil)
+	require.NoError(t, err)
+
+	defer conn.Close()
+	defer resp.Body.Close()
+
+	readMessage := func() string {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		return string(data)
+	}
+
+	assert.Equal(t, "welcome", readMessage())
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("unknown")))
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte{0xff}))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"subscribe"}`)))
+
+	assert.Equal(t, "subscribed", readMessage())
+	assert.Equal(t, "quote", readMessage())
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, 4001))
+	assert.ErrorContains(t, err, "market closed")
+
+	frames := ns.webSocketFrames.Find("quotes")
+	require.Len(t, frames, 3)
+	assert.Equal(t, "unknown", string(frames[0].Data))
+	assert.True(t, frames[1].Binary)
+	assert.Equal(t, "/w==", encodeFrameData(&frames[1]))
+	assert.Equal(t, frames[0].Connection, frames[2].Connection)
+	assert.Empty(t, ns.webSocketFrames.Find("other"))
+
+	plain, err := http.Get(testServer.URL + "/quotes")
+	require.NoError(t, err)
+
+	defer plain.Body.Close()
+
+	assert.Equal(t, http.StatusOK, plain.StatusCode)
+
+	ns.webSocketFrames.Clear()
+	assert.Empty(t, ns.webSocketFrames.Find(""))
+}
+
+func TestWebSocketRepliesKeepTheirOrder(t *testing.T) {
+	server := NewServer()
+	testServer := httptest.NewServer(server.Router)
+
+	defer testServer.Close()
+
+	require.NoError(t, server.namespaces.get(DefaultNamespace).responseManager.AddEndpoint(EndpointConfiguration{
+		EndpointID: EndpointID{Path: "/echo", HTTPMethod: http.MethodGet, WebSocket: true},
+		WebSocket: &WebSocket{
+			Replies: []WebSocketReply{
+				{
+					Matcher:  ValueMatcher{MatchType: MatchTypeExact, Value: "slow"},
+					Messages: []WebSocketMessage{{Delay: 50 * time.Millisecond, Data: "slow 1"}, {Delay: 10 * time.Millisecond, Data: "slow 2"}},
+				},
+				{
+					Matcher:  ValueMatcher{MatchType: MatchTypeExact, Value: "fast"},
+					Messages: []WebSocketMessage{{Data: "fast 1"}, {Data: "fast 2"}},
+				},
+			},
+		},
+	}))
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(testServer.URL, "http")+"/echo", nil)
+	require.NoError(t, err)
+
+	defer conn.Close()
+	defer resp.Body.Close()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("slow")))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("fast")))
+
+	for _, expected := range []string{"slow 1", "slow 2", "fast 1", "fast 2"} {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+}
+
+func TestWebSocketNearMiss(t *testing.T) {
+	responseManager := NewResponseManager()
+
+	require.NoError(t, responseManager.AddEndpoint(EndpointConfiguration{
+		ID:         "quotes",
+		EndpointID: EndpointID{Path: "/quotes", HTTPMethod: http.MethodGet, WebSocket: true},
+		WebSocket:  &WebSocket{},
+	}))
+
+	request := EndpointID{Path: "/quotes", HTTPMethod: http.MethodGet}
+
+	_, err := responseManager.MatchEndpoint(&request)
+	require.Error(t, err)
+
+	nearMisses := responseManager.NearMisses(&request)
+	require.Len(t, nearMisses, 1)
+	assert.Equal(t, []string{"request is not a WebSocket upgrade"}, nearMisses[0].Mismatches)
+}
@@ -4,11 +4,16 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/schubergphilis/mcvs-integrationtest-services/internal/app/stubserver"
 	"github.com/schubergphilis/mcvs-integrationtest-services/pkg/stubserver/models"
@@ -17,8 +22,10 @@ import (
 
 // Client represents an HTTP client for the stub server.
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL       string
+	httpClient    *http.Client
+	namespace     string
+	adminBasePath string
 }
 
 // NewClient creates a new instance of the client with the given base URL.
@@ -28,65 +35,955 @@ func NewClient(baseURL string, httpClient *http.Client) *Client {
 	}
 
 	return &Client{
-		baseURL:    baseURL,
-		httpClient: httpClient,
+		baseURL:       baseURL,
+		httpClient:    httpClient,
+		adminBasePath: stubserver.BaseURLPath,
 	}
 }
 
+// WithNamespace returns a copy of the client that scopes the admin API and the requests it sends
+// to the given namespace, so parallel tests do not share stubs, scenarios or requests.
+func (c *Client) WithNamespace(namespace string) *Client {
+	client := *c
+	client.namespace = namespace
+
+	return &client
+}
+
+// TestingT is the part of testing.TB that WithTestNamespace uses.
+type TestingT interface {
+	Cleanup(cleanup func())
+	Errorf(format string, args ...any)
+}
+
+// WithTestNamespace returns a copy of the client that is scoped to the given namespace, like
+// WithNamespace, and deletes the namespace when the test finishes, so that tests with unique
+// namespace names do not keep their stubs and journals on the stub server.
+func (c *Client) WithTestNamespace(t TestingT, namespace string) *Client {
+	client := c.WithNamespace(namespace)
+
+	t.Cleanup(func() {
+		err := client.DeleteNamespace(context.Background())
+		if err != nil {
+			t.Errorf("failed to delete namespace %s: %v", namespace, err)
+		}
+	})
+
+	return client
+}
+
+// WithAdminBasePath returns a copy of the client for a stub server that serves the admin API under
+// the given path instead of the default base path.
+func (c *Client) WithAdminBasePath(adminBasePath string) *Client {
+	client := *c
+	client.adminBasePath = adminBasePath
+
+	return &client
+}
+
 // HealthCheck checks if the stub server is healthy.
 func (c *Client) HealthCheck(ctx context.Context) error {
 	url := fmt.Sprintf("%s%s", c.baseURL, stubserver.HealthEndpoint)
 
 	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
 	if err != nil {
-		return fmt.Errorf("failed to perform health check: %w", err)
+		return fmt.Errorf("failed to perform health check: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unhealthy status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// AddResponse adds a new response to the stub server.
+func (c *Client) AddResponse(ctx context.Context, request models.EndpointRequest) error {
+	_, err := c.CreateResponse(ctx, request)
+
+	return err
+}
+
+// CreateResponse adds a new response to the stub server and returns its stub ID.
+func (c *Client) CreateResponse(ctx context.Context, request models.EndpointRequest) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ResponsesEndpoint)
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, bytes.NewBuffer(data), headers)
+	if err != nil {
+		return "", fmt.Errorf("failed to add response: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", decodeError(resp, "failed to add response")
+	}
+
+	var created models.StubCreatedResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// ImportOpenAPISpec adds a response for every operation of the OpenAPI 3 document in JSON or YAML
+// format and returns their stub IDs.
+func (c *Client) ImportOpenAPISpec(ctx context.Context, spec []byte) ([]string, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.OpenAPIEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, bytes.NewBuffer(spec), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import OpenAPI spec: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp, "failed to import OpenAPI spec")
+	}
+
+	var imported models.OpenAPIImportResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&imported)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return imported.IDs, nil
+}
+
+// GetResponse retrieves the response with the given stub ID from the stub server.
+func (c *Client) GetResponse(ctx context.Context, id string) (*models.EndpointResponse, error) {
+	url := fmt.Sprintf("%s%s%s/%s", c.baseURL, c.adminBasePath, stubserver.ResponsesEndpoint, url.PathEscape(id))
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp, "failed to get response")
+	}
+
+	var response models.EndpointResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// UpdateResponse replaces the response with the given stub ID on the stub server.
+func (c *Client) UpdateResponse(ctx context.Context, id string, request models.EndpointRequest) error {
+	return c.modifyResponse(ctx, http.MethodPut, id, request)
+}
+
+// PatchResponse updates the fields of the response with the given stub ID that are present in the
+// patch, keyed by their JSON names, e.g. {"responseStatusCode": 503}.
+func (c *Client) PatchResponse(ctx context.Context, id string, patch map[string]any) error {
+	return c.modifyResponse(ctx, http.MethodPatch, id, patch)
+}
+
+func (c *Client) modifyResponse(ctx context.Context, method, id string, request any) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s%s%s/%s", c.baseURL, c.adminBasePath, stubserver.ResponsesEndpoint, url.PathEscape(id))
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	resp, err := c.doRequest(ctx, method, url, bytes.NewBuffer(data), headers)
+	if err != nil {
+		return fmt.Errorf("failed to update response: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp, "failed to update response")
+	}
+
+	return nil
+}
+
+// DeleteResponse deletes the response with the given stub ID from the stub server.
+func (c *Client) DeleteResponse(ctx context.Context, id string) error {
+	url := fmt.Sprintf("%s%s%s/%s", c.baseURL, c.adminBasePath, stubserver.ResponsesEndpoint, url.PathEscape(id))
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete response: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp, "failed to delete response")
+	}
+
+	return nil
+}
+
+// DeleteResponsesByPath deletes all responses with the given path from the stub server.
+func (c *Client) DeleteResponsesByPath(ctx context.Context, path string) error {
+	return c.deleteResponses(ctx, url.Values{"path": []string{path}})
+}
+
+// DeleteResponsesByPathAndMethod deletes all responses with the given path and HTTP method from the stub server.
+func (c *Client) DeleteResponsesByPathAndMethod(ctx context.Context, path, method string) error {
+	return c.deleteResponses(ctx, url.Values{"path": []string{path}, "method": []string{method}})
+}
+
+func (c *Client) deleteResponses(ctx context.Context, query url.Values) error {
+	url := fmt.Sprintf("%s%s%s?%s", c.baseURL, c.adminBasePath, stubserver.ResponsesEndpoint, query.Encode())
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete responses: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp, "failed to delete responses")
+	}
+
+	return nil
+}
+
+// GetAllResponses retrieves all responses from the stub server.
+func (c *Client) GetAllResponses(ctx context.Context) ([]models.EndpointResponse, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ResponsesEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get responses: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var listResponse models.EndpointListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Endpoints, nil
+}
+
+// ExportResponses retrieves all responses from the stub server as stub definitions, which can be
+// added again with AddResponse or loaded from a file at startup.
+func (c *Client) ExportResponses(ctx context.Context) ([]models.EndpointRequest, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ExportResponsesEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export responses: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var definitions []models.EndpointRequest
+
+	err = json.NewDecoder(resp.Body).Decode(&definitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return definitions, nil
+}
+
+// DeleteAllResponses deletes all responses from the stub server.
+func (c *Client) DeleteAllResponses(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ResponsesEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete responses: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ResetSequences moves the response sequences of all endpoints back to their first response.
+func (c *Client) ResetSequences(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.SequencesEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reset sequences: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetAllScenarios retrieves all scenarios and their current state from the stub server.
+func (c *Client) GetAllScenarios(ctx context.Context) ([]models.Scenario, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ScenariosEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scenarios: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var listResponse models.ScenarioListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Scenarios, nil
+}
+
+// GetScenario retrieves a scenario and its current state from the stub server.
+func (c *Client) GetScenario(ctx context.Context, name string) (*models.Scenario, error) {
+	url := fmt.Sprintf("%s%s%s/%s", c.baseURL, c.adminBasePath, stubserver.ScenariosEndpoint, url.PathEscape(name))
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scenario: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var scenario models.Scenario
+
+	err = json.NewDecoder(resp.Body).Decode(&scenario)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &scenario, nil
+}
+
+// SetScenarioState moves a scenario on the stub server to the given state.
+func (c *Client) SetScenarioState(ctx context.Context, name, state string) error {
+	data, err := json.Marshal(models.ScenarioStateRequest{State: state})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s%s%s/%s", c.baseURL, c.adminBasePath, stubserver.ScenariosEndpoint, url.PathEscape(name))
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	resp, err := c.doRequest(ctx, http.MethodPut, url, bytes.NewBuffer(data), headers)
+	if err != nil {
+		return fmt.Errorf("failed to set scenario state: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ResetScenario moves a scenario on the stub server back to the started state.
+func (c *Client) ResetScenario(ctx context.Context, name string) error {
+	url := fmt.Sprintf("%s%s%s/%s", c.baseURL, c.adminBasePath, stubserver.ScenariosEndpoint, url.PathEscape(name))
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reset scenario: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ResetAllScenarios moves all scenarios on the stub server back to the started state.
+func (c *Client) ResetAllScenarios(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ScenariosEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reset scenarios: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetRequests retrieves the requests received by the stub server that match the filter, oldest first.
+func (c *Client) GetRequests(ctx context.Context, filter models.RequestFilter) ([]models.JournalEntry, error) {
+	query := url.Values{}
+
+	if filter.Path != "" {
+		query.Set("path", filter.Path)
+	}
+
+	if filter.Method != "" {
+		query.Set("method", filter.Method)
+	}
+
+	for name, value := range filter.Headers {
+		query.Add("header", fmt.Sprintf("%s:%s", name, value))
+	}
+
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339Nano))
+	}
+
+	if !filter.Until.IsZero() {
+		query.Set("until", filter.Until.Format(time.RFC3339Nano))
+	}
+
+	url := fmt.Sprintf("%s%s%s?%s", c.baseURL, c.adminBasePath, stubserver.RequestsEndpoint, query.Encode())
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get requests: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var listResponse models.JournalListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Requests, nil
+}
+
+// ClearRequests removes all requests from the request journal of the stub server.
+func (c *Client) ClearRequests(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.RequestsEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to clear requests: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// FindRequests retrieves the requests received by the stub server that satisfy every criterion of
+// the matcher, oldest first.
+func (c *Client) FindRequests(ctx context.Context, matcher models.RequestMatcher) ([]models.JournalEntry, error) {
+	data, err := json.Marshal(matcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.FindRequestsEndpoint)
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, bytes.NewBuffer(data), headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find requests: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp, "failed to find requests")
+	}
+
+	var listResponse models.JournalListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Requests, nil
+}
+
+// GetUnmatchedRequests retrieves the requests received by the stub server that matched no stub,
+// oldest first, each with the stubs that came closest to matching it.
+func (c *Client) GetUnmatchedRequests(ctx context.Context) ([]models.UnmatchedRequest, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.UnmatchedEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unmatched requests: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var listResponse models.UnmatchedRequestListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Requests, nil
+}
+
+// Verify checks that the stub server received exactly the given number of requests matching the
+// matcher. The error lists the requests that were actually received.
+func (c *Client) Verify(ctx context.Context, matcher models.RequestMatcher, times int) error {
+	requests, err := c.FindRequests(ctx, matcher)
+	if err != nil {
+		return err
+	}
+
+	if len(requests) == times {
+		return nil
+	}
+
+	return c.verificationError(ctx,
+		fmt.Sprintf("expected %d request(s) matching %s, but got %d", times, describeMatcher(&matcher), len(requests)))
+}
+
+// VerifyNever checks that the stub server did not receive any request matching the matcher.
+func (c *Client) VerifyNever(ctx context.Context, matcher models.RequestMatcher) error {
+	return c.Verify(ctx, matcher, 0)
+}
+
+// VerifyInOrder checks that the stub server received requests matching the matchers in the given
+// order. Other requests may have been received in between.
+func (c *Client) VerifyInOrder(ctx context.Context, matchers ...models.RequestMatcher) error {
+	var lastID int64
+
+	for i, matcher := range matchers {
+		requests, err := c.FindRequests(ctx, matcher)
+		if err != nil {
+			return err
+		}
+
+		index := slices.IndexFunc(requests, func(request models.JournalEntry) bool {
+			return request.ID > lastID
+		})
+		if index < 0 {
+			return c.verificationError(ctx,
+				fmt.Sprintf("expected request %d in order to match %s, but no such request followed the previous one", i+1, describeMatcher(&matcher)))
+		}
+
+		lastID = requests[index].ID
+	}
+
+	return nil
+}
+
+// verificationError builds an error with the given message followed by the requests the stub
+// server received.
+func (c *Client) verificationError(ctx context.Context, message string) error {
+	requests, err := c.GetRequests(ctx, models.RequestFilter{})
+	if err != nil {
+		return fmt.Errorf("%s, unable to list received requests: %w", message, err)
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString(message)
+	builder.WriteString(", received requests:")
+
+	if len(requests) == 0 {
+		builder.WriteString(" none")
+	}
+
+	for i, request := range requests {
+		builder.WriteString(fmt.Sprintf("\n  %d. %s %s", i+1, request.Method, request.URL))
+
+		if request.Body != "" {
+			builder.WriteString(fmt.Sprintf(" body: %s", request.Body))
+		}
+
+		if len(request.ContractErrors) > 0 {
+			builder.WriteString(fmt.Sprintf(" contract errors: %s", strings.Join(request.ContractErrors, "; ")))
+		}
+	}
+
+	return errors.New(builder.String())
+}
+
+func describeMatcher(matcher *models.RequestMatcher) string {
+	data, err := json.Marshal(matcher)
+	if err != nil {
+		return fmt.Sprintf("%s %s", matcher.HTTPMethod, matcher.Path)
+	}
+
+	return string(data)
+}
+
+// GetWebSocketFrames retrieves the frames received by the WebSocket stub with the given ID, or by
+// every WebSocket stub when the ID is empty, oldest first.
+func (c *Client) GetWebSocketFrames(ctx context.Context, stubID string) ([]models.WebSocketFrame, error) {
+	query := url.Values{}
+	if stubID != "" {
+		query.Set("stubId", stubID)
+	}
+
+	url := fmt.Sprintf("%s%s%s?%s", c.baseURL, c.adminBasePath, stubserver.WebSocketFramesEndpoint, query.Encode())
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get WebSocket frames: %w", err)
 	}
 	defer closeResponseBody(resp)
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unhealthy status code: %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var listResponse models.WebSocketFrameListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Frames, nil
+}
+
+// ClearWebSocketFrames removes the frames received by WebSocket stubs.
+func (c *Client) ClearWebSocketFrames(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.WebSocketFramesEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to clear WebSocket frames: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
-// AddResponse adds a new response to the stub server.
-func (c *Client) AddResponse(ctx context.Context, request models.EndpointRequest) error {
-	data, err := json.Marshal(request)
+// IssueClientCertificate issues a client certificate with the given common name, signed by the CA
+// that the stub server generated, for calling a stub server that verifies client certificates.
+func (c *Client) IssueClientCertificate(ctx context.Context, commonName string) (tls.Certificate, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ClientCertificatesEndpoint)
+
+	body, err := json.Marshal(models.ClientCertificateRequest{CommonName: commonName})
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, bytes.NewReader(body), map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to issue client certificate: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return tls.Certificate{}, decodeError(resp, "failed to issue client certificate")
+	}
+
+	var certificate models.ClientCertificateResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&certificate)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certificate.Certificate), []byte(certificate.PrivateKey))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid client certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// DeleteNamespace deletes the namespace of the client with its stubs, scenarios, journals and
+// proxy. Deleting a namespace that does not exist is not an error.
+func (c *Client) DeleteNamespace(ctx context.Context) error {
+	namespace := c.namespace
+	if namespace == "" {
+		namespace = stubserver.DefaultNamespace
+	}
+
+	url := fmt.Sprintf("%s%s%s/%s", c.baseURL, c.adminBasePath, stubserver.NamespacesEndpoint, url.PathEscape(namespace))
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete namespace: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetCallbackAttempts retrieves the attempts to send the callbacks of stubs, optionally only those
+// of the stub with the given ID.
+func (c *Client) GetCallbackAttempts(ctx context.Context, stubID string) ([]models.CallbackAttempt, error) {
+	query := url.Values{}
+	if stubID != "" {
+		query.Set("stubId", stubID)
+	}
+
+	url := fmt.Sprintf("%s%s%s?%s", c.baseURL, c.adminBasePath, stubserver.CallbacksEndpoint, query.Encode())
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get callback attempts: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var listResponse models.CallbackAttemptListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Attempts, nil
+}
+
+// ClearCallbackAttempts removes the recorded attempts to send the callbacks of stubs.
+func (c *Client) ClearCallbackAttempts(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.CallbacksEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to clear callback attempts: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// UploadGRPCDescriptors adds the gRPC services of a binary FileDescriptorSet, as written by
+// "protoc --include_imports --descriptor_set_out", to the gRPC server of the stub server. It returns
+// every service that can be stubbed.
+func (c *Client) UploadGRPCDescriptors(ctx context.Context, descriptorSet []byte) ([]models.GRPCService, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.GRPCDescriptorsEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, bytes.NewBuffer(descriptorSet), map[string]string{"Content-Type": "application/octet-stream"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload gRPC descriptors: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp, "failed to upload gRPC descriptors")
+	}
+
+	return decodeGRPCServices(resp)
+}
+
+// LoadGRPCDescriptorsFromReflection adds the gRPC services of an upstream server, given as
+// "host:port", to the gRPC server of the stub server using server reflection. It returns every
+// service that can be stubbed.
+func (c *Client) LoadGRPCDescriptorsFromReflection(ctx context.Context, target string) ([]models.GRPCService, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.GRPCReflectionEndpoint)
+
+	jsonData, err := json.Marshal(models.GRPCReflectionRequest{Target: target})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData), map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC descriptors: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp, "failed to load gRPC descriptors")
+	}
+
+	return decodeGRPCServices(resp)
+}
+
+// GetGRPCServices retrieves the gRPC services that can be stubbed.
+func (c *Client) GetGRPCServices(ctx context.Context) ([]models.GRPCService, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.GRPCServicesEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gRPC services: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return decodeGRPCServices(resp)
+}
+
+func decodeGRPCServices(resp *http.Response) ([]models.GRPCService, error) {
+	var listResponse models.GRPCServiceListResponse
+
+	err := json.NewDecoder(resp.Body).Decode(&listResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return listResponse.Services, nil
+}
+
+// GetProxy retrieves the proxy configuration of the stub server. The upstream URL is empty when the
+// proxy is disabled.
+func (c *Client) GetProxy(ctx context.Context) (*models.ProxyConfiguration, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ProxyEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	var config models.ProxyConfiguration
+
+	err = json.NewDecoder(resp.Body).Decode(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &config, nil
+}
+
+// SetProxy makes the stub server forward requests without a matching stub to an upstream.
+func (c *Client) SetProxy(ctx context.Context, config models.ProxyConfiguration) error {
+	data, err := json.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s%s%s", c.baseURL, stubserver.BaseURLPath, stubserver.ResponsesEndpoint)
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ProxyEndpoint)
 	headers := map[string]string{"Content-Type": "application/json"}
 
-	resp, err := c.doRequest(ctx, http.MethodPost, url, bytes.NewBuffer(data), headers)
+	resp, err := c.doRequest(ctx, http.MethodPut, url, bytes.NewBuffer(data), headers)
 	if err != nil {
-		return fmt.Errorf("failed to add response: %w", err)
+		return fmt.Errorf("failed to set proxy: %w", err)
 	}
 	defer closeResponseBody(resp)
 
 	if resp.StatusCode != http.StatusOK {
-		var errorResp models.ErrorResponse
+		return decodeError(resp, "failed to set proxy")
+	}
 
-		err := json.NewDecoder(resp.Body).Decode(&errorResp)
-		if err != nil {
-			return fmt.Errorf("failed with status code %d", resp.StatusCode)
-		}
+	return nil
+}
 
-		return fmt.Errorf("failed to add response: %s", errorResp.Error)
+// DisableProxy stops the stub server from forwarding requests without a matching stub.
+func (c *Client) DisableProxy(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ProxyEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to disable proxy: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
-// GetAllResponses retrieves all responses from the stub server.
-func (c *Client) GetAllResponses(ctx context.Context) ([]models.EndpointResponse, error) {
-	url := fmt.Sprintf("%s%s%s", c.baseURL, stubserver.BaseURLPath, stubserver.ResponsesEndpoint)
+// SetContract makes the stub server validate requests against the OpenAPI 3 document in JSON or
+// YAML format. When reject is set, requests that violate the contract are answered with 400.
+func (c *Client) SetContract(ctx context.Context, spec []byte, reject bool) error {
+	url := fmt.Sprintf("%s%s%s?reject=%t", c.baseURL, c.adminBasePath, stubserver.ContractEndpoint, reject)
+
+	resp, err := c.doRequest(ctx, http.MethodPut, url, bytes.NewBuffer(spec), nil)
+	if err != nil {
+		return fmt.Errorf("failed to set contract: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp, "failed to set contract")
+	}
+
+	return nil
+}
+
+// DisableContract stops the stub server from validating requests against the OpenAPI contract.
+func (c *Client) DisableContract(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ContractEndpoint)
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to disable contract: %w", err)
+	}
+	defer closeResponseBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// GetViolations retrieves the requests that violated the OpenAPI contract, oldest first.
+func (c *Client) GetViolations(ctx context.Context) ([]models.Violation, error) {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ViolationsEndpoint)
 
 	resp, err := c.doRequest(ctx, http.MethodGet, url, nil, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get responses: %w", err)
+		return nil, fmt.Errorf("failed to get violations: %w", err)
 	}
 	defer closeResponseBody(resp)
 
@@ -94,23 +991,23 @@ func (c *Client) GetAllResponses(ctx context.Context) ([]models.EndpointResponse
 		return nil, fmt.Errorf("failed with status code %d", resp.StatusCode)
 	}
 
-	var listResponse models.EndpointListResponse
+	var listResponse models.ViolationListResponse
 
 	err = json.NewDecoder(resp.Body).Decode(&listResponse)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return listResponse.Endpoints, nil
+	return listResponse.Violations, nil
 }
 
-// DeleteAllResponses deletes all responses from the stub server.
-func (c *Client) DeleteAllResponses(ctx context.Context) error {
-	url := fmt.Sprintf("%s%s%s", c.baseURL, stubserver.BaseURLPath, stubserver.ResponsesEndpoint)
+// ClearViolations removes the recorded contract violations.
+func (c *Client) ClearViolations(ctx context.Context) error {
+	url := fmt.Sprintf("%s%s%s", c.baseURL, c.adminBasePath, stubserver.ViolationsEndpoint)
 
 	resp, err := c.doRequest(ctx, http.MethodDelete, url, nil, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete responses: %w", err)
+		return fmt.Errorf("failed to clear violations: %w", err)
 	}
 	defer closeResponseBody(resp)
 
@@ -157,6 +1054,10 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body io.Read
 		req.Header.Set(key, value)
 	}
 
+	if c.namespace != "" {
+		req.Header.Set(stubserver.NamespaceHeader, c.namespace)
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
@@ -165,6 +1066,19 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body io.Read
 	return resp, nil
 }
 
+// decodeError returns an error with the message of the error response, or the status code when
+// the response has no error message.
+func decodeError(resp *http.Response, message string) error {
+	var errorResp models.ErrorResponse
+
+	err := json.NewDecoder(resp.Body).Decode(&errorResp)
+	if err != nil || errorResp.Error == "" {
+		return fmt.Errorf("failed with status code %d", resp.StatusCode)
+	}
+
+	return fmt.Errorf("%s: %s", message, errorResp.Error)
+}
+
 func closeResponseBody(resp *http.Response) {
 	if resp != nil && resp.Body != nil {
 		err := resp.Body.Close()
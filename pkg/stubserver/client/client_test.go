@@ -3,16 +3,31 @@ package client
 import (
 	"encoding/json"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/schubergphilis/mcvs-integrationtest-services/internal/app/stubserver"
 	"github.com/schubergphilis/mcvs-integrationtest-services/pkg/stubserver/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 type StubServerTestSuite struct {
@@ -89,13 +104,14 @@ func (s *StubServerTestSuite) TestAddResponseWithInvalidData() {
 			errorContains: "Path and HTTP method are required",
 		},
 		{
-			name: "missing response body",
+			name: "invalid base64 response body",
 			request: models.EndpointRequest{
 				Path:               "/test",
 				HTTPMethod:         http.MethodGet,
+				ResponseBodyBase64: "not base64!",
 				ResponseStatusCode: http.StatusOK,
 			},
-			errorContains: "Response body is required",
+			errorContains: "invalid base64 response body",
 		},
 	}
 
@@ -354,3 +370,1370 @@ func (s *StubServerTestSuite) TestSendRequestWithBody() {
 	assert.Equal(s.T(), "New User", responseData["name"])
 	assert.Equal(s.T(), "created", responseData["status"])
 }
+
+//nolint:funlen
+func (s *StubServerTestSuite) TestSendRequestWithBinaryBody() {
+	dir := s.T().TempDir()
+	err := os.WriteFile(filepath.Join(dir, "invoice.pdf"), []byte("%PDF-1.4"), 0o600)
+	assert.NoError(s.T(), err)
+
+	err = s.server.SetResponseFilesDir(dir)
+	assert.NoError(s.T(), err)
+
+	stubs := []models.EndpointRequest{
+		{
+			Path:               "/logo.png",
+			HTTPMethod:         http.MethodGet,
+			ResponseBodyBase64: "iVBORw0KGgo=",
+			ResponseStatusCode: http.StatusOK,
+		},
+		{
+			Path:               "/invoices/1",
+			HTTPMethod:         http.MethodGet,
+			ResponseBodyFile:   "invoice.pdf",
+			ResponseStatusCode: http.StatusOK,
+		},
+		{
+			Path:               "/invoices/1",
+			HTTPMethod:         http.MethodDelete,
+			ResponseStatusCode: http.StatusNoContent,
+		},
+	}
+
+	for _, stub := range stubs {
+		err = s.client.AddResponse(s.T().Context(), stub)
+		assert.NoError(s.T(), err)
+	}
+
+	tests := []struct {
+		name                string
+		method              string
+		path                string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "base64 body",
+			method:              http.MethodGet,
+			path:                "/logo.png",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "image/png",
+			expectedBody:        "\x89PNG\r\n\x1a\n",
+		},
+		{
+			name:                "body file",
+			method:              http.MethodGet,
+			path:                "/invoices/1",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/pdf",
+			expectedBody:        "%PDF-1.4",
+		},
+		{
+			name:           "empty body",
+			method:         http.MethodDelete,
+			path:           "/invoices/1",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			resp, err := s.client.SendRequest(t.Context(), tt.method, tt.path, nil, nil, nil)
+			assert.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectedContentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, string(body))
+		})
+	}
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithPathTemplate() {
+	testRequest := models.EndpointRequest{
+		Path:               "/api/v1/users/{id}",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       `{"name":"Some User"}`,
+		ResponseStatusCode: http.StatusOK,
+	}
+
+	err := s.client.AddResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+
+	for _, path := range []string{"/api/v1/users/1", "/api/v1/users/2"} {
+		resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, path, nil, nil, nil)
+		assert.NoError(s.T(), err)
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), resp.Body.Close())
+
+		assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+		assert.Equal(s.T(), `{"name":"Some User"}`, string(body))
+	}
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithValueMatchers() {
+	testRequest := models.EndpointRequest{
+		Path:       "/api/v1/orders",
+		HTTPMethod: http.MethodGet,
+		HeaderMatchers: map[string]models.ValueMatcher{
+			"Authorization": {MatchType: "regex", Value: "^Bearer .+$"},
+		},
+		QueryParamMatchers: map[string]models.ValueMatcher{
+			"page": {MatchType: "regex", Value: `^\d+$`},
+		},
+		ResponseBody:       `{"orders":[]}`,
+		ResponseStatusCode: http.StatusOK,
+	}
+
+	err := s.client.AddResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 1)
+	assert.Equal(s.T(), testRequest.HeaderMatchers, responses[0].HeaderMatchers)
+	assert.Equal(s.T(), testRequest.QueryParamMatchers, responses[0].QueryParamMatchers)
+
+	resp, err := s.client.SendRequest(s.T().Context(),
+		http.MethodGet,
+		"/api/v1/orders",
+		map[string]string{"page": "7"},
+		map[string]string{"Authorization": "Bearer some-token"},
+		nil,
+	)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithListMatchers() {
+	testRequest := models.EndpointRequest{
+		Path:       "/api/v1/articles",
+		HTTPMethod: http.MethodGet,
+		QueryParamListMatchers: map[string]models.ListMatcher{
+			"tag": {MatchType: "exactSet", Values: []string{"go", "http"}},
+		},
+		HeaderListMatchers: map[string]models.ListMatcher{
+			"x-tenant": {MatchType: "anyOf", Values: []string{"acme", "globex"}},
+		},
+		ResponseBody:       `{"articles":[]}`,
+		ResponseStatusCode: http.StatusOK,
+	}
+
+	err := s.client.AddResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 1)
+	assert.Equal(s.T(), testRequest.QueryParamListMatchers, responses[0].QueryParamListMatchers)
+	assert.Equal(s.T(), testRequest.HeaderListMatchers, responses[0].HeaderListMatchers)
+
+	fallback := models.EndpointRequest{
+		Path:               "/api/v1/articles",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       `{"fallback":true}`,
+		ResponseStatusCode: http.StatusOK,
+	}
+
+	err = s.client.AddResponse(s.T().Context(), fallback)
+	assert.NoError(s.T(), err)
+
+	tenant := map[string]string{"X-Tenant": "acme"}
+
+	tests := []struct {
+		name         string
+		path         string
+		headers      map[string]string
+		expectedBody string
+	}{
+		{name: "all values", path: "/api/v1/articles?tag=http&tag=go", headers: tenant, expectedBody: testRequest.ResponseBody},
+		{name: "missing value", path: "/api/v1/articles?tag=go", headers: tenant, expectedBody: fallback.ResponseBody},
+		{name: "missing header", path: "/api/v1/articles?tag=http&tag=go", expectedBody: fallback.ResponseBody},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			resp, err := s.client.SendRequest(t.Context(), http.MethodGet, tt.path, nil, tt.headers, nil)
+			assert.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, string(body))
+		})
+	}
+}
+
+func (s *StubServerTestSuite) TestAddResponseWithInvalidMatcher() {
+	testRequest := models.EndpointRequest{
+		Path:       "/api/v1/orders",
+		HTTPMethod: http.MethodGet,
+		HeaderMatchers: map[string]models.ValueMatcher{
+			"Authorization": {MatchType: "wildcard", Value: "*"},
+		},
+		ResponseBody:       `{"orders":[]}`,
+		ResponseStatusCode: http.StatusOK,
+	}
+
+	err := s.client.AddResponse(s.T().Context(), testRequest)
+	assert.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "invalid match type: wildcard")
+}
+
+//nolint:funlen
+func (s *StubServerTestSuite) TestSendRequestWithBodyMatchers() {
+	testRequests := []models.EndpointRequest{
+		{
+			Path:               "/api/orders",
+			HTTPMethod:         http.MethodPost,
+			BodyMatchers:       []models.BodyMatcher{{MatchType: "jsonPath", Expression: "$.type", Matcher: &models.ValueMatcher{MatchType: "exact", Value: "express"}}},
+			ResponseBody:       `{"delivery":"tomorrow"}`,
+			ResponseStatusCode: http.StatusCreated,
+		},
+		{
+			Path:               "/api/orders",
+			HTTPMethod:         http.MethodPost,
+			BodyMatchers:       []models.BodyMatcher{{MatchType: "jsonPath", Expression: "$.type", Matcher: &models.ValueMatcher{MatchType: "exact", Value: "standard"}}},
+			ResponseBody:       `{"delivery":"next week"}`,
+			ResponseStatusCode: http.StatusCreated,
+		},
+	}
+
+	for _, testRequest := range testRequests {
+		err := s.client.AddResponse(s.T().Context(), testRequest)
+		assert.NoError(s.T(), err)
+	}
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 2)
+	assert.Len(s.T(), responses[0].BodyMatchers, 1)
+
+	resp, err := s.client.SendRequest(s.T().Context(),
+		http.MethodPost,
+		"/api/orders",
+		nil,
+		map[string]string{"Content-Type": "application/json"},
+		strings.NewReader(`{"type":"standard","items":[]}`),
+	)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusCreated, resp.StatusCode)
+	assert.Equal(s.T(), `{"delivery":"next week"}`, string(body))
+
+	sendPickup := func() (int, string) {
+		resp, err := s.client.SendRequest(s.T().Context(), http.MethodPost, "/api/orders", nil, nil, strings.NewReader(`{"type":"pickup"}`))
+		assert.NoError(s.T(), err)
+
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(s.T(), err)
+
+		return resp.StatusCode, string(body)
+	}
+
+	statusCode, _ := sendPickup()
+	assert.Equal(s.T(), http.StatusNotFound, statusCode, "a body that matches no body matcher is not served")
+
+	err = s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:               "/api/orders",
+		HTTPMethod:         http.MethodPost,
+		ResponseBody:       `{"delivery":"unknown"}`,
+		ResponseStatusCode: http.StatusAccepted,
+	})
+	assert.NoError(s.T(), err)
+
+	statusCode, fallbackBody := sendPickup()
+	assert.Equal(s.T(), http.StatusAccepted, statusCode)
+	assert.Equal(s.T(), `{"delivery":"unknown"}`, fallbackBody)
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithResponseSequence() {
+	testRequest := models.EndpointRequest{
+		Path:       "/api/retry",
+		HTTPMethod: http.MethodGet,
+		Responses: []models.SequenceResponse{
+			{ResponseBody: "unavailable", ResponseStatusCode: http.StatusServiceUnavailable, Times: 2},
+			{ResponseBody: "ok", ResponseStatusCode: http.StatusOK},
+		},
+		SequencePolicy: "exhaust",
+	}
+
+	err := s.client.AddResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+
+	expected := []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK, http.StatusNotFound}
+
+	sendRequests := func() []int {
+		statusCodes := []int{}
+
+		for range expected {
+			resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/retry", nil, nil, nil)
+			assert.NoError(s.T(), err)
+			assert.NoError(s.T(), resp.Body.Close())
+
+			statusCodes = append(statusCodes, resp.StatusCode)
+		}
+
+		return statusCodes
+	}
+
+	assert.Equal(s.T(), expected, sendRequests())
+
+	err = s.client.ResetSequences(s.T().Context())
+	assert.NoError(s.T(), err)
+
+	assert.Equal(s.T(), expected, sendRequests())
+}
+
+//nolint:funlen
+func (s *StubServerTestSuite) TestScenarios() {
+	testRequests := []models.EndpointRequest{
+		{
+			Path:                  "/api/cart",
+			HTTPMethod:            http.MethodGet,
+			ScenarioName:          "cart",
+			RequiredScenarioState: "Started",
+			ResponseBody:          `{"items":[]}`,
+			ResponseStatusCode:    http.StatusOK,
+		},
+		{
+			Path:               "/api/cart/items",
+			HTTPMethod:         http.MethodPost,
+			ScenarioName:       "cart",
+			NewScenarioState:   "item added",
+			ResponseBody:       `{"status":"added"}`,
+			ResponseStatusCode: http.StatusCreated,
+		},
+		{
+			Path:                  "/api/cart",
+			HTTPMethod:            http.MethodGet,
+			ScenarioName:          "cart",
+			RequiredScenarioState: "item added",
+			ResponseBody:          `{"items":["a"]}`,
+			ResponseStatusCode:    http.StatusOK,
+		},
+	}
+
+	for _, testRequest := range testRequests {
+		err := s.client.AddResponse(s.T().Context(), testRequest)
+		assert.NoError(s.T(), err)
+	}
+
+	getCart := func() string {
+		resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/cart", nil, nil, nil)
+		assert.NoError(s.T(), err)
+
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(s.T(), err)
+
+		return string(body)
+	}
+
+	assert.Equal(s.T(), `{"items":[]}`, getCart())
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodPost, "/api/cart/items", nil, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+
+	scenario, err := s.client.GetScenario(s.T().Context(), "cart")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "item added", scenario.State)
+	assert.Equal(s.T(), `{"items":["a"]}`, getCart())
+
+	err = s.client.ResetScenario(s.T().Context(), "cart")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), `{"items":[]}`, getCart())
+
+	err = s.client.SetScenarioState(s.T().Context(), "cart", "item added")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), `{"items":["a"]}`, getCart())
+
+	err = s.client.ResetAllScenarios(s.T().Context())
+	assert.NoError(s.T(), err)
+
+	scenarios, err := s.client.GetAllScenarios(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.Scenario{{Name: "cart", State: "Started"}}, scenarios)
+
+	err = s.client.SetScenarioState(s.T().Context(), "unknown", "foo")
+	assert.Error(s.T(), err)
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithResponseTemplating() {
+	testRequest := models.EndpointRequest{
+		Path:               "/api/users/{id}",
+		HTTPMethod:         http.MethodPut,
+		ResponseTemplating: true,
+		ResponseHeaders:    map[string]string{"X-Request-Id": `{{index .Headers "X-Request-Id"}}`},
+		ResponseBody:       `{"id":"{{.PathParams.id}}","name":"{{.JSON.name}}"}`,
+		ResponseStatusCode: http.StatusOK,
+	}
+
+	err := s.client.AddResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+
+	resp, err := s.client.SendRequest(s.T().Context(),
+		http.MethodPut,
+		"/api/users/123",
+		nil,
+		map[string]string{"Content-Type": "application/json", "X-Request-Id": "abc"},
+		strings.NewReader(`{"name":"Jane"}`),
+	)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(s.T(), "abc", resp.Header.Get("X-Request-Id"))
+	assert.Equal(s.T(), `{"id":"123","name":"Jane"}`, string(body))
+}
+
+func (s *StubServerTestSuite) TestGetResponseWithResponseTemplating() {
+	id, err := s.client.CreateResponse(s.T().Context(), models.EndpointRequest{
+		Path:                       "/api/users/{id}",
+		HTTPMethod:                 http.MethodGet,
+		ResponseTemplating:         true,
+		ResponseBody:               `{"id":"{{.PathParams.id}}"}`,
+		ResponseStatusCodeTemplate: "{{if eq .PathParams.id \"0\"}}404{{else}}200{{end}}",
+	})
+	assert.NoError(s.T(), err)
+
+	response, err := s.client.GetResponse(s.T().Context(), id)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), response.ResponseTemplating)
+	assert.Equal(s.T(), "{{if eq .PathParams.id \"0\"}}404{{else}}200{{end}}", response.ResponseStatusCodeTemplate)
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 1)
+	assert.True(s.T(), responses[0].ResponseTemplating)
+	assert.Equal(s.T(), response.ResponseStatusCodeTemplate, responses[0].ResponseStatusCodeTemplate)
+}
+
+func (s *StubServerTestSuite) TestGetRequests() {
+	testRequest := models.EndpointRequest{
+		Path:               "/api/notifications",
+		HTTPMethod:         http.MethodPost,
+		ResponseBody:       `{"status":"sent"}`,
+		ResponseStatusCode: http.StatusAccepted,
+	}
+
+	err := s.client.AddResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+
+	resp, err := s.client.SendRequest(s.T().Context(),
+		http.MethodPost,
+		"/api/notifications",
+		map[string]string{"channel": "email"},
+		map[string]string{"X-Tenant": "acme"},
+		strings.NewReader(`{"to":"user@example.com"}`),
+	)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+
+	resp, err = s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/unknown", nil, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+
+	requests, err := s.client.GetRequests(s.T().Context(), models.RequestFilter{})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), requests, 2)
+
+	requests, err = s.client.GetRequests(s.T().Context(), models.RequestFilter{
+		Path:    "/api/notifications",
+		Method:  http.MethodPost,
+		Headers: map[string]string{"X-Tenant": "acme"},
+		Since:   time.Now().Add(-time.Minute),
+	})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), requests, 1)
+	assert.Equal(s.T(), `{"to":"user@example.com"}`, requests[0].Body)
+	assert.Equal(s.T(), []string{"email"}, requests[0].QueryParams["channel"])
+	assert.NotEmpty(s.T(), requests[0].MatchedStubID)
+
+	requests, err = s.client.GetRequests(s.T().Context(), models.RequestFilter{Path: "/api/unknown"})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), requests, 1)
+	assert.Empty(s.T(), requests[0].MatchedStubID)
+
+	err = s.client.ClearRequests(s.T().Context())
+	assert.NoError(s.T(), err)
+
+	requests, err = s.client.GetRequests(s.T().Context(), models.RequestFilter{})
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), requests)
+}
+
+func (s *StubServerTestSuite) TestVerify() {
+	for _, path := range []string{"/api/orders", "/api/notifications"} {
+		err := s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+			Path:               path,
+			HTTPMethod:         http.MethodPost,
+			ResponseBody:       "ok",
+			ResponseStatusCode: http.StatusOK,
+		})
+		assert.NoError(s.T(), err)
+	}
+
+	for _, path := range []string{"/api/orders", "/api/notifications"} {
+		resp, err := s.client.SendRequest(s.T().Context(), http.MethodPost, path, nil, nil, strings.NewReader(`{"id":"1"}`))
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), resp.Body.Close())
+	}
+
+	orders := models.RequestMatcher{Path: "/api/orders", HTTPMethod: http.MethodPost}
+	notifications := models.RequestMatcher{
+		Path:         "/api/notifications",
+		HTTPMethod:   http.MethodPost,
+		BodyMatchers: []models.BodyMatcher{{MatchType: "jsonSubset", Value: `{"id":"1"}`}},
+	}
+
+	assert.NoError(s.T(), s.client.Verify(s.T().Context(), notifications, 1))
+	assert.NoError(s.T(), s.client.VerifyNever(s.T().Context(), models.RequestMatcher{Path: "/api/orders", HTTPMethod: http.MethodGet}))
+	assert.NoError(s.T(), s.client.VerifyInOrder(s.T().Context(), orders, notifications))
+
+	err := s.client.Verify(s.T().Context(), notifications, 2)
+	assert.ErrorContains(s.T(), err, "expected 2 request(s)")
+	assert.ErrorContains(s.T(), err, "2. POST /api/notifications")
+
+	err = s.client.VerifyNever(s.T().Context(), orders)
+	assert.ErrorContains(s.T(), err, "1. POST /api/orders")
+
+	err = s.client.VerifyInOrder(s.T().Context(), notifications, orders)
+	assert.ErrorContains(s.T(), err, "expected request 2 in order")
+
+	err = s.client.Verify(s.T().Context(), models.RequestMatcher{Path: "[", PathMatchType: "regex"}, 1)
+	assert.ErrorContains(s.T(), err, "invalid regular expression")
+}
+
+//nolint:funlen
+func (s *StubServerTestSuite) TestUnmatchedRequests() {
+	err := s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		ID:                 "open-orders",
+		Path:               "/api/orders",
+		HTTPMethod:         http.MethodGet,
+		QueryParamsToMatch: map[string]string{"status": "open"},
+		ResponseBody:       "[]",
+		ResponseStatusCode: http.StatusOK,
+	})
+	assert.NoError(s.T(), err)
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodPost, "/api/orders", map[string]string{"status": "closed"}, nil, nil)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+
+	var unmatched models.UnmatchedResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&unmatched)
+	assert.NoError(s.T(), err)
+
+	nearMiss := models.NearMiss{
+		StubID:     "open-orders",
+		Path:       "/api/orders",
+		HTTPMethod: http.MethodGet,
+		Mismatches: []string{"method POST does not match GET", "query parameter status is closed, expected exact(open)"},
+	}
+	assert.NotEmpty(s.T(), unmatched.Error)
+	assert.Equal(s.T(), []models.NearMiss{nearMiss}, unmatched.NearMisses)
+
+	requests, err := s.client.GetUnmatchedRequests(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), requests, 1)
+	assert.Equal(s.T(), "/api/orders?status=closed", requests[0].Request.URL)
+	assert.Equal(s.T(), []models.NearMiss{nearMiss}, requests[0].NearMisses)
+}
+
+func (s *StubServerTestSuite) TestAdminBasePath() {
+	server := stubserver.NewServerWithConfig(stubserver.Config{AdminBasePath: "/admin", JournalSize: 1})
+	testServer := httptest.NewServer(server.Router)
+
+	defer testServer.Close()
+
+	client := NewClient(testServer.URL, nil).WithAdminBasePath("/admin")
+
+	err := client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:               "/stubserver/responses",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       "stubbed",
+		ResponseStatusCode: http.StatusOK,
+	})
+	assert.NoError(s.T(), err)
+
+	for range 2 {
+		resp, err := client.SendRequest(s.T().Context(), http.MethodGet, "/stubserver/responses", nil, nil, nil)
+		assert.NoError(s.T(), err)
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(s.T(), err)
+		assert.NoError(s.T(), resp.Body.Close())
+		assert.Equal(s.T(), "stubbed", string(body))
+	}
+
+	requests, err := client.GetRequests(s.T().Context(), models.RequestFilter{})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), requests, 1)
+}
+
+func (s *StubServerTestSuite) TestImportOpenAPISpec() {
+	spec := `{
+		"openapi": "3.0.3",
+		"info": {"title": "Orders", "version": "1.0.0"},
+		"servers": [{"url": "https://orders.example.com/api"}],
+		"paths": {
+			"/orders/{id}": {
+				"get": {
+					"operationId": "getOrder",
+					"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+					"responses": {
+						"200": {
+							"description": "The order",
+							"content": {"application/json": {"schema": {
+								"type": "object",
+								"properties": {"id": {"type": "string", "example": "42"}, "total": {"type": "number"}}
+							}}}
+						}
+					}
+				}
+			}
+		}
+	}`
+
+	ids, err := s.client.ImportOpenAPISpec(s.T().Context(), []byte(spec))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []string{"getOrder"}, ids)
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/orders/42", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(s.T(), "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(s.T(), `{"id":"42","total":0}`, string(body))
+
+	_, err = s.client.ImportOpenAPISpec(s.T().Context(), []byte(`{"openapi": "3.0.3"}`))
+	assert.ErrorContains(s.T(), err, "invalid OpenAPI spec")
+}
+
+//nolint:funlen
+func (s *StubServerTestSuite) TestContractValidation() {
+	contract := `{
+		"openapi": "3.0.3",
+		"info": {"title": "Orders", "version": "1.0.0"},
+		"paths": {
+			"/api/orders": {
+				"get": {
+					"parameters": [{"name": "status", "in": "query", "schema": {"type": "string", "enum": ["open", "closed"]}}],
+					"responses": {"200": {"description": "The orders"}}
+				}
+			}
+		}
+	}`
+
+	err := s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:               "/api/orders",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       "[]",
+		ResponseStatusCode: http.StatusOK,
+	})
+	assert.NoError(s.T(), err)
+
+	err = s.client.SetContract(s.T().Context(), []byte(contract), false)
+	assert.NoError(s.T(), err)
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/orders", map[string]string{"status": "lost"}, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+
+	violations, err := s.client.GetViolations(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), violations, 1)
+	assert.Equal(s.T(), "GET /api/orders", violations[0].Operation)
+	assert.Equal(s.T(), "/api/orders?status=lost", violations[0].URL)
+
+	err = s.client.SetContract(s.T().Context(), []byte(contract), true)
+	assert.NoError(s.T(), err)
+
+	resp, err = s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/orders", map[string]string{"status": "lost"}, nil, nil)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	var rejected models.ContractViolationResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&rejected)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(s.T(), "request violates the OpenAPI contract", rejected.Error)
+	assert.NotEmpty(s.T(), rejected.Violation.Errors)
+
+	unmatched, err := s.client.GetUnmatchedRequests(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), unmatched, 1)
+	assert.Equal(s.T(), rejected.Violation.Errors, unmatched[0].Request.ContractErrors)
+
+	err = s.client.Verify(s.T().Context(), models.RequestMatcher{Path: "/api/orders"}, 1)
+	assert.ErrorContains(s.T(), err, "2. GET /api/orders?status=lost contract errors: "+rejected.Violation.Errors[0])
+
+	assert.NoError(s.T(), s.client.ClearViolations(s.T().Context()))
+	assert.NoError(s.T(), s.client.DisableContract(s.T().Context()))
+
+	violations, err = s.client.GetViolations(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), violations)
+
+	err = s.client.SetContract(s.T().Context(), []byte(`{"openapi": "3.0.3"}`), false)
+	assert.ErrorContains(s.T(), err, "invalid OpenAPI spec")
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithStream() {
+	err := s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:               "/api/orders/export",
+		HTTPMethod:         http.MethodGet,
+		ResponseHeaders:    map[string]string{"Content-Type": "application/x-ndjson"},
+		ResponseStatusCode: http.StatusOK,
+		Stream: &models.Stream{
+			Mode: "chunked",
+			Chunks: []models.StreamChunk{
+				{Data: "{\"id\":\"1\"}\n"},
+				{Data: "{\"id\":\"2\"}\n", DelayMilliseconds: 50},
+			},
+		},
+	})
+	assert.NoError(s.T(), err)
+
+	start := time.Now()
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/orders/export", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(s.T(), []string{"chunked"}, resp.TransferEncoding)
+	assert.Equal(s.T(), "application/x-ndjson", resp.Header.Get("Content-Type"))
+	assert.Equal(s.T(), "{\"id\":\"1\"}\n{\"id\":\"2\"}\n", string(body))
+	assert.GreaterOrEqual(s.T(), time.Since(start), 50*time.Millisecond)
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 1)
+	assert.Equal(s.T(), 50, responses[0].Stream.Chunks[1].DelayMilliseconds)
+
+	err = s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:       "/api/orders/export",
+		HTTPMethod: http.MethodPost,
+		Stream:     &models.Stream{Mode: "websocket"},
+	})
+	assert.ErrorContains(s.T(), err, "invalid stream mode: websocket")
+}
+
+func (s *StubServerTestSuite) TestWebSocket() {
+	err := s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		ID:         "chat",
+		Path:       "/api/chat",
+		HTTPMethod: http.MethodGet,
+		WebSocket: &models.WebSocket{
+			OnConnect: []models.WebSocketMessage{{Data: "hello"}},
+			Replies: []models.WebSocketReply{{
+				Matcher:  models.ValueMatcher{MatchType: "regex", Value: "^ping"},
+				Messages: []models.WebSocketMessage{{Data: "pong"}},
+			}},
+		},
+	})
+	assert.NoError(s.T(), err)
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(s.T().Context(), "ws"+strings.TrimPrefix(s.testServer.URL, "http")+"/api/chat", nil)
+	assert.NoError(s.T(), err)
+
+	defer resp.Body.Close()
+
+	_, message, err := conn.ReadMessage()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "hello", string(message))
+
+	assert.NoError(s.T(), conn.WriteMessage(websocket.TextMessage, []byte("ping 1")))
+
+	_, message, err = conn.ReadMessage()
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "pong", string(message))
+
+	err = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), conn.Close())
+
+	frames, err := s.client.GetWebSocketFrames(s.T().Context(), "chat")
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), frames, 1)
+	assert.Equal(s.T(), "ping 1", frames[0].Data)
+
+	assert.NoError(s.T(), s.client.ClearWebSocketFrames(s.T().Context()))
+
+	frames, err = s.client.GetWebSocketFrames(s.T().Context(), "")
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), frames)
+
+	plain, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/chat", nil, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), plain.Body.Close())
+	assert.Equal(s.T(), http.StatusNotFound, plain.StatusCode)
+}
+
+func (s *StubServerTestSuite) TestCallbacks() {
+	received := make(chan string, 1)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	err := s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		ID:                 "refunds",
+		Path:               "/api/refunds",
+		HTTPMethod:         http.MethodPost,
+		ResponseStatusCode: http.StatusAccepted,
+		Callbacks: []models.Callback{{
+			URL:     receiver.URL + "/hooks",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"refundId":"{{.JSON.id}}","status":"completed"}`,
+			Retries: 1,
+		}},
+	})
+	assert.NoError(s.T(), err)
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodPost, "/api/refunds", nil, nil, strings.NewReader(`{"id":"r-1"}`))
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
+
+	select {
+	case body := <-received:
+		assert.JSONEq(s.T(), `{"refundId":"r-1","status":"completed"}`, body)
+	case <-time.After(time.Second):
+		s.T().Fatal("callback was not received")
+	}
+
+	var attempts []models.CallbackAttempt
+
+	assert.Eventually(s.T(), func() bool {
+		attempts, err = s.client.GetCallbackAttempts(s.T().Context(), "refunds")
+
+		return err == nil && len(attempts) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	if !assert.Len(s.T(), attempts, 1) {
+		return
+	}
+
+	assert.Equal(s.T(), http.StatusNoContent, attempts[0].StatusCode)
+	assert.Equal(s.T(), receiver.URL+"/hooks", attempts[0].URL)
+	assert.Equal(s.T(), 1, attempts[0].Attempt)
+
+	assert.NoError(s.T(), s.client.ClearCallbackAttempts(s.T().Context()))
+
+	attempts, err = s.client.GetCallbackAttempts(s.T().Context(), "")
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), attempts)
+}
+
+//nolint:funlen
+func (s *StubServerTestSuite) TestGRPC() {
+	descriptorSet, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto)},
+	})
+	assert.NoError(s.T(), err)
+
+	services, err := s.client.UploadGRPCDescriptors(s.T().Context(), descriptorSet)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.GRPCService{{
+		Name:    "grpc.health.v1.Health",
+		Methods: []string{"/grpc.health.v1.Health/Check", "/grpc.health.v1.Health/List", "/grpc.health.v1.Health/Watch"},
+	}}, services)
+
+	_, err = s.client.UploadGRPCDescriptors(s.T().Context(), []byte("invalid"))
+	assert.ErrorContains(s.T(), err, "invalid descriptor set")
+
+	err = s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:         "/grpc.health.v1.Health/Check",
+		HTTPMethod:   http.MethodPost,
+		BodyMatchers: []models.BodyMatcher{{MatchType: "jsonSubset", Value: `{"service":"orders"}`}},
+		ResponseBody: `{"status":"SERVING"}`,
+		GRPC:         &models.GRPC{Trailers: map[string]string{"x-checked-by": "stub"}},
+	})
+	assert.NoError(s.T(), err)
+
+	err = s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:       "/grpc.health.v1.Health/Watch",
+		HTTPMethod: http.MethodPost,
+		GRPC: &models.GRPC{
+			Code:     "UNAVAILABLE",
+			Messages: []models.GRPCMessage{{Data: `{"status":"NOT_SERVING"}`}, {DelayMilliseconds: 10, Data: `{"status":"SERVING"}`}},
+		},
+	})
+	assert.NoError(s.T(), err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(s.T(), err)
+
+	grpcServer := s.server.GRPCServer()
+
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(s.T(), err)
+
+	defer conn.Close()
+
+	healthClient := healthpb.NewHealthClient(conn)
+
+	var trailer metadata.MD
+
+	check, err := healthClient.Check(s.T().Context(), &healthpb.HealthCheckRequest{Service: "orders"}, grpc.Trailer(&trailer))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), healthpb.HealthCheckResponse_SERVING, check.GetStatus())
+	assert.Equal(s.T(), []string{"stub"}, trailer.Get("x-checked-by"))
+
+	watch, err := healthClient.Watch(s.T().Context(), &healthpb.HealthCheckRequest{Service: "orders"})
+	assert.NoError(s.T(), err)
+
+	statuses := make([]healthpb.HealthCheckResponse_ServingStatus, 0)
+
+	for {
+		response, err := watch.Recv()
+		if err != nil {
+			assert.Equal(s.T(), codes.Unavailable, status.Code(err))
+
+			break
+		}
+
+		statuses = append(statuses, response.GetStatus())
+	}
+
+	assert.Equal(s.T(), []healthpb.HealthCheckResponse_ServingStatus{
+		healthpb.HealthCheckResponse_NOT_SERVING, healthpb.HealthCheckResponse_SERVING,
+	}, statuses)
+
+	requests, err := s.client.GetRequests(s.T().Context(), models.RequestFilter{Path: "/grpc.health.v1.Health/{method}"})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), requests, 2)
+
+	services, err = s.client.GetGRPCServices(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), services, 1)
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithFault() {
+	testCases := []struct {
+		name          string
+		fault         models.Fault
+		errorContains string
+		bodyError     bool
+	}{
+		{name: "connection reset", fault: models.Fault{Type: "connectionReset"}, errorContains: "failed to send request"},
+		{name: "empty response", fault: models.Fault{Type: "emptyResponse"}, errorContains: "EOF"},
+		{name: "malformed chunk", fault: models.Fault{Type: "malformedChunk"}, bodyError: true},
+		{name: "truncated body", fault: models.Fault{Type: "truncatedBody"}, bodyError: true},
+	}
+
+	for _, tc := range testCases {
+		s.T().Run(tc.name, func(t *testing.T) {
+			err := s.client.DeleteAllResponses(t.Context())
+			assert.NoError(t, err)
+
+			fault := tc.fault
+
+			err = s.client.AddResponse(t.Context(), models.EndpointRequest{
+				Path:               "/api/orders",
+				HTTPMethod:         http.MethodGet,
+				ResponseBody:       `{"orders":[{"id":"1"},{"id":"2"}]}`,
+				ResponseStatusCode: http.StatusOK,
+				Fault:              &fault,
+			})
+			assert.NoError(t, err)
+
+			resp, err := s.client.SendRequest(t.Context(), http.MethodGet, "/api/orders", nil, nil, nil)
+			if !tc.bodyError {
+				assert.ErrorContains(t, err, tc.errorContains)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			_, err = io.ReadAll(resp.Body)
+			assert.Error(t, err)
+			assert.NoError(t, resp.Body.Close())
+		})
+	}
+}
+
+func (s *StubServerTestSuite) TestSendRequestWithDelay() {
+	err := s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:               "/api/orders",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       "ok",
+		ResponseStatusCode: http.StatusOK,
+		Fault:              &models.Fault{FixedDelayMilliseconds: 100, RandomDelayMinMilliseconds: 10, RandomDelayMaxMilliseconds: 20},
+	})
+	assert.NoError(s.T(), err)
+
+	start := time.Now()
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/orders", nil, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.GreaterOrEqual(s.T(), time.Since(start), 110*time.Millisecond)
+
+	err = s.client.AddResponse(s.T().Context(), models.EndpointRequest{
+		Path:               "/api/invalid",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       "ok",
+		ResponseStatusCode: http.StatusOK,
+		Fault:              &models.Fault{Type: "timeout"},
+	})
+	assert.ErrorContains(s.T(), err, "invalid fault type")
+}
+
+func (s *StubServerTestSuite) TestProxyWithRecording() {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer upstream.Close()
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/users/1", nil, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+
+	err = s.client.SetProxy(s.T().Context(), models.ProxyConfiguration{UpstreamURL: upstream.URL, Record: true})
+	assert.NoError(s.T(), err)
+
+	config, err := s.client.GetProxy(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), upstream.URL, config.UpstreamURL)
+	assert.True(s.T(), config.Record)
+
+	resp, err = s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/users/1", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode)
+	assert.JSONEq(s.T(), `{"path":"/api/users/1"}`, string(body))
+
+	upstream.Close()
+
+	err = s.client.DisableProxy(s.T().Context())
+	assert.NoError(s.T(), err)
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 1)
+	assert.Equal(s.T(), "/api/users/1", responses[0].Path)
+
+	resp, err = s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/users/1", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	body, err = io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(s.T(), `{"path":"/api/users/1"}`, string(body))
+
+	err = s.client.SetProxy(s.T().Context(), models.ProxyConfiguration{UpstreamURL: "not a url"})
+	assert.ErrorContains(s.T(), err, "invalid upstream URL")
+}
+
+func (s *StubServerTestSuite) TestProxyRecordsEveryRequestBody() {
+	var forwarded atomic.Int32
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ordered":` + string(body) + `,"page":"` + r.URL.Query().Get("page") + `"}`))
+	}))
+	defer upstream.Close()
+
+	err := s.client.SetProxy(s.T().Context(), models.ProxyConfiguration{UpstreamURL: upstream.URL, Record: true})
+	assert.NoError(s.T(), err)
+
+	send := func(page, body string) string {
+		resp, err := s.client.SendRequest(s.T().Context(), http.MethodPost, "/api/orders", map[string]string{"page": page}, nil, strings.NewReader(body))
+		assert.NoError(s.T(), err)
+
+		defer resp.Body.Close()
+
+		responseBody, err := io.ReadAll(resp.Body)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), http.StatusCreated, resp.StatusCode)
+
+		return string(responseBody)
+	}
+
+	assert.JSONEq(s.T(), `{"ordered":"book","page":"1"}`, send("1", `"book"`))
+	assert.JSONEq(s.T(), `{"ordered":"pen","page":"1"}`, send("1", `"pen"`))
+	assert.JSONEq(s.T(), `{"ordered":"pen","page":"2"}`, send("2", `"pen"`))
+	assert.Equal(s.T(), int32(3), forwarded.Load(), "requests with another body or query are forwarded instead of answered by a recording")
+
+	assert.NoError(s.T(), s.client.DisableProxy(s.T().Context()))
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 3)
+
+	assert.JSONEq(s.T(), `{"ordered":"book","page":"1"}`, send("1", `"book"`))
+	assert.JSONEq(s.T(), `{"ordered":"pen","page":"1"}`, send("1", `"pen"`))
+	assert.JSONEq(s.T(), `{"ordered":"pen","page":"2"}`, send("2", `"pen"`))
+	assert.Equal(s.T(), int32(3), forwarded.Load())
+}
+
+func (s *StubServerTestSuite) TestExportResponses() {
+	testRequest := models.EndpointRequest{
+		Path:               "/users/{id}",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       `{"id":"{{.PathParams.id}}"}`,
+		ResponseStatusCode: http.StatusOK,
+		ResponseTemplating: true,
+		Fault:              &models.Fault{FixedDelayMilliseconds: 10},
+	}
+
+	id, err := s.client.CreateResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+
+	testRequest.ID = id
+
+	definitions, err := s.client.ExportResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []models.EndpointRequest{testRequest}, definitions)
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/stubserver/responses/export", map[string]string{"format": "yaml"}, nil, nil)
+	assert.NoError(s.T(), err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Contains(s.T(), string(body), "path: /users/{id}")
+	assert.Contains(s.T(), string(body), "responseTemplating: true")
+	assert.NotContains(s.T(), string(body), "queryParamsToMatch")
+}
+
+func (s *StubServerTestSuite) TestNamespaces() {
+	teamA := s.client.WithNamespace("team-a")
+	teamB := s.client.WithNamespace("team-b")
+
+	for _, tc := range []struct {
+		client *Client
+		body   string
+	}{
+		{client: teamA, body: "team a"},
+		{client: teamB, body: "team b"},
+	} {
+		err := tc.client.AddResponse(s.T().Context(), models.EndpointRequest{
+			Path:               "/api/orders",
+			HTTPMethod:         http.MethodGet,
+			ResponseBody:       tc.body,
+			ResponseStatusCode: http.StatusOK,
+		})
+		assert.NoError(s.T(), err)
+	}
+
+	resp, err := teamA.SendRequest(s.T().Context(), http.MethodGet, "/api/orders", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), "team a", string(body))
+
+	resp, err = s.client.SendRequest(s.T().Context(), http.MethodGet, "/namespaces/team-b/api/orders", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	body, err = io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), "team b", string(body))
+
+	resp, err = s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/orders", nil, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+
+	err = teamA.DeleteAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+
+	responses, err := teamB.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 1)
+
+	assert.NoError(s.T(), teamA.Verify(s.T().Context(), models.RequestMatcher{Path: "/api/orders"}, 1))
+	assert.NoError(s.T(), teamB.Verify(s.T().Context(), models.RequestMatcher{Path: "/api/orders"}, 1))
+	assert.NoError(s.T(), s.client.Verify(s.T().Context(), models.RequestMatcher{Path: "/api/orders"}, 1))
+
+	resp, err = s.client.SendRequest(s.T().Context(), http.MethodGet, "/namespaces/team-b/stubserver/responses", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	var listResponse models.EndpointListResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&listResponse)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Len(s.T(), listResponse.Endpoints, 1)
+
+	assert.NoError(s.T(), teamB.DeleteNamespace(s.T().Context()))
+	assert.NoError(s.T(), teamB.DeleteNamespace(s.T().Context()), "deleting a missing namespace is not an error")
+
+	responses, err = teamB.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), responses)
+}
+
+func (s *StubServerTestSuite) TestIssueClientCertificate() {
+	_, err := s.client.IssueClientCertificate(s.T().Context(), "orders")
+	assert.ErrorContains(s.T(), err, "No CA was generated")
+
+	server := stubserver.NewServer()
+
+	_, err = server.ConfigureTLS(stubserver.TLSConfig{})
+	assert.NoError(s.T(), err)
+
+	testServer := httptest.NewServer(server.Router)
+	defer testServer.Close()
+
+	cert, err := NewClient(testServer.URL, nil).IssueClientCertificate(s.T().Context(), "orders")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "orders", cert.Leaf.Subject.CommonName)
+}
+
+func (s *StubServerTestSuite) TestWithTestNamespace() {
+	s.T().Run("test", func(t *testing.T) {
+		client := s.client.WithTestNamespace(t, "temporary")
+
+		err := client.AddResponse(t.Context(), models.EndpointRequest{
+			Path:               "/api/orders",
+			HTTPMethod:         http.MethodGet,
+			ResponseStatusCode: http.StatusOK,
+		})
+		assert.NoError(t, err)
+	})
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodDelete, "/stubserver/namespaces/temporary", nil, nil, nil)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode, "the namespace was deleted when the test finished")
+}
+
+//nolint:funlen
+func (s *StubServerTestSuite) TestResponseCRUD() {
+	testRequest := models.EndpointRequest{
+		Path:               "/api/orders",
+		HTTPMethod:         http.MethodGet,
+		ResponseBody:       "[]",
+		ResponseStatusCode: http.StatusOK,
+	}
+
+	id, err := s.client.CreateResponse(s.T().Context(), testRequest)
+	assert.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), id)
+
+	response, err := s.client.GetResponse(s.T().Context(), id)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), id, response.ID)
+	assert.Equal(s.T(), "/api/orders", response.Path)
+
+	testRequest.ResponseBody = `[{"id":"1"}]`
+	err = s.client.UpdateResponse(s.T().Context(), id, testRequest)
+	assert.NoError(s.T(), err)
+
+	err = s.client.PatchResponse(s.T().Context(), id, map[string]any{"responseStatusCode": http.StatusPartialContent})
+	assert.NoError(s.T(), err)
+
+	resp, err := s.client.SendRequest(s.T().Context(), http.MethodGet, "/api/orders", nil, nil, nil)
+	assert.NoError(s.T(), err)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+	assert.NoError(s.T(), resp.Body.Close())
+	assert.Equal(s.T(), http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(s.T(), `[{"id":"1"}]`, string(body))
+
+	requests, err := s.client.GetRequests(s.T().Context(), models.RequestFilter{})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), requests, 1)
+	assert.Equal(s.T(), id, requests[0].MatchedStubID)
+
+	err = s.client.PatchResponse(s.T().Context(), id, map[string]any{"httpMethod": "FETCH"})
+	assert.ErrorContains(s.T(), err, "invalid HTTP method")
+
+	err = s.client.DeleteResponse(s.T().Context(), id)
+	assert.NoError(s.T(), err)
+
+	_, err = s.client.GetResponse(s.T().Context(), id)
+	assert.ErrorContains(s.T(), err, "stub not found")
+
+	err = s.client.UpdateResponse(s.T().Context(), id, testRequest)
+	assert.ErrorContains(s.T(), err, "stub not found")
+
+	for _, request := range []models.EndpointRequest{
+		{Path: "/api/users", HTTPMethod: http.MethodGet, ResponseBody: "[]"},
+		{Path: "/api/users", HTTPMethod: http.MethodPost, ResponseBody: "{}"},
+		{Path: "/api/items", HTTPMethod: http.MethodGet, ResponseBody: "[]"},
+	} {
+		assert.NoError(s.T(), s.client.AddResponse(s.T().Context(), request))
+	}
+
+	err = s.client.DeleteResponsesByPathAndMethod(s.T().Context(), "/api/users", http.MethodPost)
+	assert.NoError(s.T(), err)
+
+	responses, err := s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 2)
+
+	err = s.client.DeleteResponsesByPath(s.T().Context(), "/api/users")
+	assert.NoError(s.T(), err)
+
+	responses, err = s.client.GetAllResponses(s.T().Context())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), responses, 1)
+	assert.Equal(s.T(), "/api/items", responses[0].Path)
+}
@@ -1,14 +1,56 @@
 package models
 
-// EndpointRequest represents the request body for adding a new endpoint.
+import (
+	"time"
+)
+
+// EndpointRequest represents the request body for adding a new endpoint. The ID is optional and
+// generated by the stub server when empty. When several endpoints match a request, the endpoint
+// with the highest priority serves it. The HTTP method "ANY" matches every method. Binary response
+// bodies are given base64 encoded or as a file in the response files directory of the server.
 type EndpointRequest struct {
-	Path               string            `json:"path"`
-	HTTPMethod         string            `json:"httpMethod"`
-	QueryParamsToMatch map[string]string `json:"queryParamsToMatch,omitempty"`
-	HeadersToMatch     map[string]string `json:"headersToMatch,omitempty"`
-	ResponseHeaders    map[string]string `json:"responseHeaders,omitempty"`
-	ResponseBody       string            `json:"responseBody"`
-	ResponseStatusCode int               `json:"responseStatusCode"`
+	ID                         string                  `json:"id,omitempty"`
+	Path                       string                  `json:"path"`
+	HTTPMethod                 string                  `json:"httpMethod"`
+	QueryParamsToMatch         map[string]string       `json:"queryParamsToMatch,omitempty"`
+	HeadersToMatch             map[string]string       `json:"headersToMatch,omitempty"`
+	PathMatchType              string                  `json:"pathMatchType,omitempty"`
+	QueryParamMatchers         map[string]ValueMatcher `json:"queryParamMatchers,omitempty"`
+	HeaderMatchers             map[string]ValueMatcher `json:"headerMatchers,omitempty"`
+	QueryParamListMatchers     map[string]ListMatcher  `json:"queryParamListMatchers,omitempty"`
+	HeaderListMatchers         map[string]ListMatcher  `json:"headerListMatchers,omitempty"`
+	ClientCertSubjectMatcher   *ValueMatcher           `json:"clientCertSubjectMatcher,omitempty"`
+	BodyMatchers               []BodyMatcher           `json:"bodyMatchers,omitempty"`
+	ResponseHeaders            map[string]string       `json:"responseHeaders,omitempty"`
+	ResponseBody               string                  `json:"responseBody"`
+	ResponseBodyBase64         string                  `json:"responseBodyBase64,omitempty"`
+	ResponseBodyFile           string                  `json:"responseBodyFile,omitempty"`
+	ResponseStatusCode         int                     `json:"responseStatusCode"`
+	ResponseTemplating         bool                    `json:"responseTemplating,omitempty"`
+	ResponseStatusCodeTemplate string                  `json:"responseStatusCodeTemplate,omitempty"`
+	Responses                  []SequenceResponse      `json:"responses,omitempty"`
+	SequencePolicy             string                  `json:"sequencePolicy,omitempty"`
+	ScenarioName               string                  `json:"scenarioName,omitempty"`
+	RequiredScenarioState      string                  `json:"requiredScenarioState,omitempty"`
+	NewScenarioState           string                  `json:"newScenarioState,omitempty"`
+	Fault                      *Fault                  `json:"fault,omitempty"`
+	Stream                     *Stream                 `json:"stream,omitempty"`
+	WebSocket                  *WebSocket              `json:"webSocket,omitempty"`
+	GRPC                       *GRPC                   `json:"grpc,omitempty"`
+	Callbacks                  []Callback              `json:"callbacks,omitempty"`
+	Priority                   int                     `json:"priority,omitempty"`
+}
+
+// StubCreatedResponse represents the response body for adding a new endpoint.
+type StubCreatedResponse struct {
+	ID string `json:"id"`
+}
+
+// OpenAPIImportResponse represents the response body for generating endpoints from an OpenAPI
+// specification.
+type OpenAPIImportResponse struct {
+	IDs     []string `json:"ids"`
+	Skipped []string `json:"skipped,omitempty"`
 }
 
 // EndpointListResponse EndpointListRequest represents the request body for listing endpoints.
@@ -18,13 +60,349 @@ type EndpointListResponse struct {
 
 // EndpointResponse represents the response body for an endpoint.
 type EndpointResponse struct {
-	Path               string            `json:"path"`
-	HTTPMethod         string            `json:"httpMethod"`
-	QueryParamsToMatch map[string]string `json:"queryParamsToMatch,omitempty"`
-	HeadersToMatch     map[string]string `json:"headersToMatch,omitempty"`
-	ResponseHeaders    map[string]string `json:"responseHeaders,omitempty"`
-	ResponseBody       string            `json:"responseBody"`
-	ResponseStatusCode int               `json:"responseStatusCode"`
+	ID                         string                  `json:"id"`
+	Path                       string                  `json:"path"`
+	HTTPMethod                 string                  `json:"httpMethod"`
+	QueryParamsToMatch         map[string]string       `json:"queryParamsToMatch,omitempty"`
+	HeadersToMatch             map[string]string       `json:"headersToMatch,omitempty"`
+	PathMatchType              string                  `json:"pathMatchType,omitempty"`
+	QueryParamMatchers         map[string]ValueMatcher `json:"queryParamMatchers,omitempty"`
+	HeaderMatchers             map[string]ValueMatcher `json:"headerMatchers,omitempty"`
+	QueryParamListMatchers     map[string]ListMatcher  `json:"queryParamListMatchers,omitempty"`
+	HeaderListMatchers         map[string]ListMatcher  `json:"headerListMatchers,omitempty"`
+	ClientCertSubjectMatcher   *ValueMatcher           `json:"clientCertSubjectMatcher,omitempty"`
+	BodyMatchers               []BodyMatcher           `json:"bodyMatchers,omitempty"`
+	ResponseHeaders            map[string]string       `json:"responseHeaders,omitempty"`
+	ResponseBody               string                  `json:"responseBody"`
+	ResponseBodyBase64         string                  `json:"responseBodyBase64,omitempty"`
+	ResponseBodyFile           string                  `json:"responseBodyFile,omitempty"`
+	ResponseStatusCode         int                     `json:"responseStatusCode"`
+	ResponseTemplating         bool                    `json:"responseTemplating,omitempty"`
+	ResponseStatusCodeTemplate string                  `json:"responseStatusCodeTemplate,omitempty"`
+	Responses                  []SequenceResponse      `json:"responses,omitempty"`
+	SequencePolicy             string                  `json:"sequencePolicy,omitempty"`
+	ScenarioName               string                  `json:"scenarioName,omitempty"`
+	RequiredScenarioState      string                  `json:"requiredScenarioState,omitempty"`
+	NewScenarioState           string                  `json:"newScenarioState,omitempty"`
+	Fault                      *Fault                  `json:"fault,omitempty"`
+	Stream                     *Stream                 `json:"stream,omitempty"`
+	WebSocket                  *WebSocket              `json:"webSocket,omitempty"`
+	GRPC                       *GRPC                   `json:"grpc,omitempty"`
+	Callbacks                  []Callback              `json:"callbacks,omitempty"`
+	Priority                   int                     `json:"priority,omitempty"`
+}
+
+// ValueMatcher represents a matcher for a path, query parameter or header value. Supported match
+// types are "exact", "regex", "prefix", "contains" and "present". A regular expression matches when
+// it matches a part of the value, unless it is anchored with ^ and $.
+type ValueMatcher struct {
+	MatchType string `json:"matchType"`
+	Value     string `json:"value,omitempty"`
+}
+
+// ListMatcher describes how every value of a repeated query parameter or header should be
+// matched. Supported match types are "containsAll", "exactSet" and "anyOf".
+type ListMatcher struct {
+	MatchType string   `json:"matchType"`
+	Values    []string `json:"values"`
+}
+
+// BodyMatcher represents a matcher for the request body. Supported match types are "exact",
+// "json", "jsonSubset", "jsonPath", "regex" and "form".
+type BodyMatcher struct {
+	MatchType  string                  `json:"matchType"`
+	Value      string                  `json:"value,omitempty"`
+	Expression string                  `json:"expression,omitempty"`
+	Matcher    *ValueMatcher           `json:"matcher,omitempty"`
+	FormFields map[string]ValueMatcher `json:"formFields,omitempty"`
+}
+
+// SequenceResponse represents one response in a sequence of responses that an endpoint serves on
+// successive calls. Times is the number of consecutive calls the response is served for.
+// ResponseStatusCodeTemplate is only used when response templating is enabled for the endpoint.
+type SequenceResponse struct {
+	ResponseHeaders            map[string]string `json:"responseHeaders,omitempty"`
+	ResponseBody               string            `json:"responseBody"`
+	ResponseBodyBase64         string            `json:"responseBodyBase64,omitempty"`
+	ResponseBodyFile           string            `json:"responseBodyFile,omitempty"`
+	ResponseStatusCode         int               `json:"responseStatusCode"`
+	ResponseStatusCodeTemplate string            `json:"responseStatusCodeTemplate,omitempty"`
+	Times                      int               `json:"times,omitempty"`
+}
+
+// Fault represents the latency and failures injected when an endpoint is served. A random delay
+// between the minimum and maximum is added to the fixed delay. Supported fault types are
+// "connectionReset", "emptyResponse", "malformedChunk" and "truncatedBody". Probability is the
+// share of calls, between 0 and 1, that fail and defaults to 1 when omitted.
+type Fault struct {
+	FixedDelayMilliseconds     int      `json:"fixedDelayMilliseconds,omitempty"`
+	RandomDelayMinMilliseconds int      `json:"randomDelayMinMilliseconds,omitempty"`
+	RandomDelayMaxMilliseconds int      `json:"randomDelayMaxMilliseconds,omitempty"`
+	Type                       string   `json:"type,omitempty"`
+	Probability                *float64 `json:"probability,omitempty"`
+}
+
+// Stream represents a response body that is written as a series of chunks. Supported modes are
+// "sse", which writes every chunk as a Server-Sent Event, and "chunked". With holdOpen, the
+// connection stays open after the last chunk until the client disconnects.
+type Stream struct {
+	Mode     string        `json:"mode"`
+	Chunks   []StreamChunk `json:"chunks"`
+	HoldOpen bool          `json:"holdOpen,omitempty"`
+}
+
+// StreamChunk represents a chunk of a stream that is written after the delay. The id and event
+// fields are only supported for Server-Sent Events.
+type StreamChunk struct {
+	DelayMilliseconds int    `json:"delayMilliseconds,omitempty"`
+	Data              string `json:"data"`
+	ID                string `json:"id,omitempty"`
+	Event             string `json:"event,omitempty"`
+}
+
+// WebSocket represents the conversation of a stub that upgrades matching requests to WebSocket.
+// The onConnect messages are sent when the connection is established, every received text message
+// is answered with the messages of the first reply whose matcher matches it, and close optionally
+// ends the conversation after its delay.
+type WebSocket struct {
+	OnConnect []WebSocketMessage `json:"onConnect,omitempty"`
+	Replies   []WebSocketReply   `json:"replies,omitempty"`
+	Close     *WebSocketClose    `json:"close,omitempty"`
+}
+
+// WebSocketMessage represents a text message that is sent after the delay.
+type WebSocketMessage struct {
+	DelayMilliseconds int    `json:"delayMilliseconds,omitempty"`
+	Data              string `json:"data"`
+}
+
+// WebSocketReply represents the messages that are sent in reply to a matching message.
+type WebSocketReply struct {
+	Matcher  ValueMatcher       `json:"matcher"`
+	Messages []WebSocketMessage `json:"messages"`
+}
+
+// WebSocketClose represents the close frame, with a close code like 1000 or 4000-4999, that ends
+// the conversation after the delay.
+type WebSocketClose struct {
+	DelayMilliseconds int    `json:"delayMilliseconds,omitempty"`
+	Code              int    `json:"code"`
+	Reason            string `json:"reason,omitempty"`
+}
+
+// WebSocketFrame represents a data frame received by a WebSocket stub. The data of binary frames
+// is base64 encoded.
+type WebSocketFrame struct {
+	ID         int64     `json:"id"`
+	Timestamp  time.Time `json:"timestamp"`
+	StubID     string    `json:"stubId"`
+	Connection int64     `json:"connection"`
+	Binary     bool      `json:"binary,omitempty"`
+	Data       string    `json:"data"`
+}
+
+// WebSocketFrameListResponse represents the response body for querying received WebSocket frames.
+type WebSocketFrameListResponse struct {
+	Frames []WebSocketFrame `json:"frames"`
+}
+
+// GRPC represents the status, trailers and messages of a stub for a gRPC method. The path of the
+// stub is the full method name, like "/package.Service/Method", the HTTP method is POST and the
+// response body is the JSON representation of the response message of a unary method. The code is
+// a status code name like "NOT_FOUND" or number and defaults to OK. The messages of a server
+// streaming method default to the response body.
+type GRPC struct {
+	Code     string            `json:"code,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Trailers map[string]string `json:"trailers,omitempty"`
+	Messages []GRPCMessage     `json:"messages,omitempty"`
+}
+
+// GRPCMessage represents a JSON response message of a server streaming method that is sent after the delay.
+type GRPCMessage struct {
+	DelayMilliseconds int    `json:"delayMilliseconds,omitempty"`
+	Data              string `json:"data"`
+}
+
+// GRPCReflectionRequest represents the request body for loading the descriptors of the gRPC
+// services of an upstream server, given as "host:port", with server reflection.
+type GRPCReflectionRequest struct {
+	Target string `json:"target"`
+}
+
+// GRPCService represents a gRPC service that can be stubbed, with the full names of its methods.
+type GRPCService struct {
+	Name    string   `json:"name"`
+	Methods []string `json:"methods"`
+}
+
+// GRPCServiceListResponse represents the response body for listing the gRPC services.
+type GRPCServiceListResponse struct {
+	Services []GRPCService `json:"services"`
+}
+
+// Callback represents a request that a stub sends after it was served, like a webhook call. The
+// URL, headers and body are response templates with the triggering request as data. The method
+// defaults to POST. An attempt that fails or is answered with a status code other than 2xx is
+// retried up to retries times.
+type Callback struct {
+	URL                    string            `json:"url"`
+	Method                 string            `json:"method,omitempty"`
+	Headers                map[string]string `json:"headers,omitempty"`
+	Body                   string            `json:"body,omitempty"`
+	DelayMilliseconds      int               `json:"delayMilliseconds,omitempty"`
+	Retries                int               `json:"retries,omitempty"`
+	RetryDelayMilliseconds int               `json:"retryDelayMilliseconds,omitempty"`
+}
+
+// CallbackAttempt represents an attempt to send a callback of a stub. The callback is the index of
+// the callback in the callbacks of the stub. The status code is 0 when the attempt failed with an error.
+type CallbackAttempt struct {
+	ID                   int64     `json:"id"`
+	Timestamp            time.Time `json:"timestamp"`
+	StubID               string    `json:"stubId"`
+	Callback             int       `json:"callback"`
+	Attempt              int       `json:"attempt"`
+	Method               string    `json:"method"`
+	URL                  string    `json:"url"`
+	Body                 string    `json:"body,omitempty"`
+	StatusCode           int       `json:"statusCode,omitempty"`
+	Error                string    `json:"error,omitempty"`
+	DurationMilliseconds int       `json:"durationMilliseconds"`
+}
+
+// CallbackAttemptListResponse represents the response body for querying callback attempts.
+type CallbackAttemptListResponse struct {
+	Attempts []CallbackAttempt `json:"attempts"`
+}
+
+// Scenario represents a scenario and the state it is currently in.
+type Scenario struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// ScenarioListResponse represents the response body for listing scenarios.
+type ScenarioListResponse struct {
+	Scenarios []Scenario `json:"scenarios"`
+}
+
+// ScenarioStateRequest represents the request body for setting the state of a scenario.
+type ScenarioStateRequest struct {
+	State string `json:"state"`
+}
+
+// JournalEntry represents a request that was received by the stub server.
+type JournalEntry struct {
+	ID                int64               `json:"id"`
+	Timestamp         time.Time           `json:"timestamp"`
+	Method            string              `json:"method"`
+	URL               string              `json:"url"`
+	Path              string              `json:"path"`
+	QueryParams       map[string][]string `json:"queryParams,omitempty"`
+	Headers           map[string][]string `json:"headers,omitempty"`
+	Body              string              `json:"body,omitempty"`
+	MatchedStubID     string              `json:"matchedStubId,omitempty"`
+	ClientCertSubject string              `json:"clientCertSubject,omitempty"`
+	ContractErrors    []string            `json:"contractErrors,omitempty"`
+}
+
+// JournalListResponse represents the response body for querying the request journal.
+type JournalListResponse struct {
+	Requests []JournalEntry `json:"requests"`
+}
+
+// NearMiss represents a stub that did not match a request, with a description of every criterion
+// of the stub that the request failed.
+type NearMiss struct {
+	StubID     string   `json:"stubId"`
+	Path       string   `json:"path"`
+	HTTPMethod string   `json:"httpMethod"`
+	Mismatches []string `json:"mismatches"`
+}
+
+// UnmatchedResponse represents the response body for a request that matched no stub. It lists
+// the stubs that came closest to matching, closest first.
+type UnmatchedResponse struct {
+	Error      string     `json:"error"`
+	NearMisses []NearMiss `json:"nearMisses"`
+}
+
+// UnmatchedRequest represents a request that matched no stub, with the stubs that came closest.
+type UnmatchedRequest struct {
+	Request    JournalEntry `json:"request"`
+	NearMisses []NearMiss   `json:"nearMisses"`
+}
+
+// UnmatchedRequestListResponse represents the response body for listing unmatched requests.
+type UnmatchedRequestListResponse struct {
+	Requests []UnmatchedRequest `json:"requests"`
+}
+
+// RequestFilter represents the criteria for querying the request journal. The path may contain
+// templates and wildcards, header names are case-insensitive and zero times are ignored.
+type RequestFilter struct {
+	Path    string
+	Method  string
+	Headers map[string]string
+	Since   time.Time
+	Until   time.Time
+}
+
+// RequestMatcher represents the criteria for finding requests in the request journal. The fields
+// follow the same rules as the matching fields of EndpointRequest, except that every criterion has
+// to be satisfied and an empty path or HTTP method matches any request.
+type RequestMatcher struct {
+	Path                     string                  `json:"path,omitempty"`
+	HTTPMethod               string                  `json:"httpMethod,omitempty"`
+	QueryParamsToMatch       map[string]string       `json:"queryParamsToMatch,omitempty"`
+	HeadersToMatch           map[string]string       `json:"headersToMatch,omitempty"`
+	PathMatchType            string                  `json:"pathMatchType,omitempty"`
+	QueryParamMatchers       map[string]ValueMatcher `json:"queryParamMatchers,omitempty"`
+	HeaderMatchers           map[string]ValueMatcher `json:"headerMatchers,omitempty"`
+	QueryParamListMatchers   map[string]ListMatcher  `json:"queryParamListMatchers,omitempty"`
+	HeaderListMatchers       map[string]ListMatcher  `json:"headerListMatchers,omitempty"`
+	ClientCertSubjectMatcher *ValueMatcher           `json:"clientCertSubjectMatcher,omitempty"`
+	BodyMatchers             []BodyMatcher           `json:"bodyMatchers,omitempty"`
+}
+
+// ProxyConfiguration represents the upstream that requests without a matching stub are forwarded
+// to. With Record enabled, every forwarded exchange is added as a new stub.
+type ProxyConfiguration struct {
+	UpstreamURL string `json:"upstreamUrl"`
+	Record      bool   `json:"record,omitempty"`
+}
+
+// Violation represents a request that does not conform to the OpenAPI contract.
+type Violation struct {
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	Operation string    `json:"operation,omitempty"`
+	Errors    []string  `json:"errors"`
+}
+
+// ViolationListResponse represents the response body for listing contract violations.
+type ViolationListResponse struct {
+	Violations []Violation `json:"violations"`
+}
+
+// ContractViolationResponse represents the response body for a request that was rejected because
+// it violates the OpenAPI contract.
+type ContractViolationResponse struct {
+	Error     string    `json:"error"`
+	Violation Violation `json:"violation"`
+}
+
+// ClientCertificateRequest represents the request body for issuing a client certificate.
+type ClientCertificateRequest struct {
+	CommonName string `json:"commonName"`
+}
+
+// ClientCertificateResponse represents a client certificate and its private key, both PEM encoded.
+type ClientCertificateResponse struct {
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"privateKey"`
 }
 
 // ErrorResponse represents the error response body.